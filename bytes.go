@@ -32,6 +32,8 @@ func BytesReader(data any) io.Reader {
 // BytesMD5 returns the hex encoded MD5 hash of data.
 // WARNING: MD5 is cryptographically broken and should NOT be used for security purposes.
 // This function is suitable for checksums, cache keys, and other non-security applications only.
+//
+// Deprecated: Use StringHash(data, HashMD5) or BytesHash.
 func BytesMD5(data string) string {
 	hash := md5.New() //#nosec
 	hash.Write([]byte(data))
@@ -0,0 +1,396 @@
+package dry
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+/*
+ConfigLoader loads a configuration struct from multiple sources.
+Later sources override values of earlier ones:
+
+ 1. `default` struct tags for fields that are zero
+ 2. the values of the struct passed to Load
+ 3. Files in the order of Filenames, found via FileFind in SearchDirs
+ 4. DotenvFiles in their order, found via FileFind in SearchDirs
+ 5. environment variables if Env is true
+ 6. command line flags from Args if Args is not nil
+
+Files with the extension .json or .xml are unmarshalled,
+all other files are parsed as key=value lines like FileGetConfig.
+Nested JSON objects and XML elements map to nested struct fields,
+keys in key=value files are dot separated like "database.url".
+The key of a field is the name from its `config` tag or the Go field name,
+keys are compared case insensitive and ignoring '_' and '-'.
+
+Dotenv files and environment variables use the variable names
+documented at EnvBind with EnvPrefix as prefix.
+
+Command line flags are named by the lower case words of the key
+joined with '-' and a '.' between nested structs, like -database.max-conns.
+A `flag` tag overrides the name, `flag:"-"` disables the flag,
+and a `usage` tag provides the help text.
+
+Usage example:
+
+	config := Config{Port: 8080}
+	loader := dry.ConfigLoader{
+		SearchDirs:  []string{".", "/etc/myservice"},
+		Filenames:   []string{"config.json"},
+		DotenvFiles: []string{".env"},
+		Env:         true,
+		EnvPrefix:   "MYSERVICE_",
+		Args:        os.Args[1:],
+	}
+	sources, err := loader.Load(&config)
+*/
+type ConfigLoader struct {
+	SearchDirs  []string
+	Filenames   []string
+	DotenvFiles []string
+	Env         bool
+	EnvPrefix   string
+	// Args are parsed as command line flags if not nil
+	Args []string
+	// Validate is called with the struct pointer after all sources
+	// have been applied. Structs implementing ConfigValidator
+	// are validated by their Validate method additionally.
+	Validate func(structPtr any) error
+}
+
+// ConfigValidator can be implemented by configuration structs
+// to be validated by ConfigLoader.Load.
+type ConfigValidator interface {
+	Validate() error
+}
+
+// ConfigSources maps the Go field paths of a configuration struct
+// like "Database.URL" to a description of the source that provided its value.
+// Descriptions are "default", "file:<path>", "dotenv:<path>",
+// "env:<NAME>" or "flag:-<name>".
+type ConfigSources map[string]string
+
+// String returns one "field: source" line per field sorted by field.
+func (sources ConfigSources) String() string {
+	var b strings.Builder
+	for _, field := range StringMapSortedKeys(sources) {
+		fmt.Fprintf(&b, "%s: %s\n", field, sources[field])
+	}
+	return b.String()
+}
+
+// Load applies all configured sources to the struct pointed to by structPtr
+// and returns which source provided the value of every field.
+// Problems of all sources are collected and returned as ErrorList,
+// with the exception of flag.ErrHelp which is returned as is.
+// Fields with a `env:",required"` tag option must be provided by a source.
+func (l *ConfigLoader) Load(structPtr any) (ConfigSources, error) {
+	v := reflect.ValueOf(structPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("structPtr must be pointer to a struct, but is %T", structPtr)
+	}
+	fields := bindStructFields(v.Elem(), l.EnvPrefix, "", nil, nil)
+	sources := make(ConfigSources, len(fields))
+
+	var errs ErrorList
+	set := func(field *bindStructField, value any, source string) {
+		err := configSetValue(field.Value, value, field.Tag.Get("sep"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s for field %s: %w", source, field.Path, err))
+			return
+		}
+		sources[field.Path] = source
+	}
+
+	for i := range fields {
+		field := &fields[i]
+		if !field.Value.IsZero() {
+			sources[field.Path] = "default"
+		} else if value, ok := field.Tag.Lookup("default"); ok {
+			set(field, value, "default")
+		}
+	}
+
+	for _, filename := range l.Filenames {
+		filePath, found := l.find(filename)
+		if !found {
+			continue
+		}
+		values, err := configFileValues(filePath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for i := range fields {
+			if value, ok := values[configNormalizeKey(strings.Join(fields[i].Key, "."))]; ok {
+				set(&fields[i], value, "file:"+filePath)
+			}
+		}
+	}
+
+	for _, filename := range l.DotenvFiles {
+		filePath, found := l.find(filename)
+		if !found {
+			continue
+		}
+		env, err := FileGetDotenv(filePath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for i := range fields {
+			if value, ok := env[fields[i].Name]; ok {
+				set(&fields[i], value, "dotenv:"+filePath)
+			}
+		}
+	}
+
+	if l.Env {
+		for i := range fields {
+			if value, ok := os.LookupEnv(fields[i].Name); ok {
+				set(&fields[i], value, "env:"+fields[i].Name)
+			}
+		}
+	}
+
+	if l.Args != nil {
+		flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		for i := range fields {
+			name := configFlagName(&fields[i])
+			if name == "" {
+				continue
+			}
+			flags.Var(&configFlag{
+				isBool: fields[i].Value.Kind() == reflect.Bool,
+				set: func(value string) error {
+					set(&fields[i], value, "flag:-"+name)
+					return nil
+				},
+			}, name, fields[i].Tag.Get("usage"))
+		}
+		err := flags.Parse(l.Args)
+		if errors.Is(err, flag.ErrHelp) {
+			flags.SetOutput(os.Stderr)
+			flags.PrintDefaults()
+			return sources, err
+		}
+		errs.Collect(err)
+	}
+
+	for i := range fields {
+		if fields[i].Required && sources[fields[i].Path] == "" {
+			errs = append(errs, fmt.Errorf("required field %s has no value", fields[i].Path))
+		}
+	}
+	if len(errs) > 0 {
+		return sources, errs
+	}
+
+	if validator, ok := structPtr.(ConfigValidator); ok {
+		errs.Collect(validator.Validate())
+	}
+	if l.Validate != nil {
+		errs.Collect(l.Validate(structPtr))
+	}
+	return sources, errs.Err()
+}
+
+func (l *ConfigLoader) find(filename string) (filePath string, found bool) {
+	if len(l.SearchDirs) == 0 || filepath.IsAbs(filename) {
+		return filename, FileExists(filename)
+	}
+	return FileFind(l.SearchDirs, filename)
+}
+
+// configFlag implements flag.Value for a bindStructField
+type configFlag struct {
+	isBool bool
+	set    func(string) error
+	value  string
+}
+
+func (f *configFlag) String() string     { return f.value }
+func (f *configFlag) IsBoolFlag() bool   { return f.isBool }
+func (f *configFlag) Set(s string) error { f.value = s; return f.set(s) }
+
+func configFlagName(field *bindStructField) string {
+	if name, ok := field.Tag.Lookup("flag"); ok {
+		if name == "-" {
+			return ""
+		}
+		return name
+	}
+	words := make([]string, len(field.Key))
+	for i, key := range field.Key {
+		words[i] = strings.ReplaceAll(strings.ToLower(StringToUpperSnakeCase(key)), "_", "-")
+	}
+	return strings.Join(words, ".")
+}
+
+// configNormalizeKey lower cases key and removes '_' and '-'
+// so that "max_conns", "max-conns" and "MaxConns" are identical.
+func configNormalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// configFileValues returns the values of a configuration file
+// with normalized dot separated keys.
+// The values are of type string, []string or json.RawMessage.
+func configFileValues(filename string) (map[string]any, error) {
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		data, err := FileGetBytes(filename)
+		if err != nil {
+			return nil, err
+		}
+		err = configFlattenJSON("", data, values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+	case ".xml":
+		data, err := FileGetBytes(filename)
+		if err != nil {
+			return nil, err
+		}
+		err = configFlattenXML(data, values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+	default:
+		config, err := FileGetConfig(filename)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range config {
+			values[configNormalizeKey(key)] = value
+		}
+	}
+	return values, nil
+}
+
+// configFlattenJSON stores the raw JSON of every object member
+// with its dot separated key in values, recursing into nested objects.
+func configFlattenJSON(prefix string, data []byte, values map[string]any) error {
+	var object map[string]json.RawMessage
+	err := json.Unmarshal(data, &object)
+	if err != nil {
+		return err
+	}
+	for key, raw := range object {
+		key = prefix + configNormalizeKey(key)
+		values[key] = raw
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+			err = configFlattenJSON(key+".", trimmed, values)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// configFlattenXML stores the text of every leaf element below the root element
+// with the dot separated element names as key in values.
+// Repeated elements are stored as []string.
+func configFlattenXML(data []byte, values map[string]any) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		path     []string
+		text     strings.Builder
+		hasChild []bool
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(hasChild) > 0 {
+				hasChild[len(hasChild)-1] = true
+			}
+			path = append(path, configNormalizeKey(t.Name.Local))
+			hasChild = append(hasChild, false)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if !hasChild[len(hasChild)-1] && len(path) > 1 {
+				key := strings.Join(path[1:], ".")
+				value := strings.TrimSpace(text.String())
+				switch existing := values[key].(type) {
+				case nil:
+					values[key] = value
+				case string:
+					values[key] = []string{existing, value}
+				case []string:
+					values[key] = append(existing, value)
+				}
+			}
+			path = path[:len(path)-1]
+			hasChild = hasChild[:len(hasChild)-1]
+			text.Reset()
+		}
+	}
+}
+
+// configSetValue sets v from a string, []string or json.RawMessage value.
+func configSetValue(v reflect.Value, value any, sliceSep string) error {
+	switch value := value.(type) {
+	case string:
+		return reflectSetFromString(v, value, sliceSep)
+
+	case []string:
+		if v.Kind() != reflect.Slice {
+			return reflectSetFromString(v, value[len(value)-1], sliceSep)
+		}
+		slice := reflect.MakeSlice(v.Type(), len(value), len(value))
+		for i, s := range value {
+			err := reflectSetFromString(slice.Index(i), s, sliceSep)
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+
+	case json.RawMessage:
+		var s string
+		if json.Unmarshal(value, &s) == nil {
+			return reflectSetFromString(v, s, sliceSep)
+		}
+		err := json.Unmarshal(value, v.Addr().Interface())
+		if err == nil || v.Kind() != reflect.Slice {
+			return err
+		}
+		// Parse the elements separately to support strings for non JSON types
+		var elements []json.RawMessage
+		if json.Unmarshal(value, &elements) != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(v.Type(), len(elements), len(elements))
+		for i, element := range elements {
+			err = configSetValue(slice.Index(i), element, sliceSep)
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return fmt.Errorf("unsupported config value type %T", value)
+}
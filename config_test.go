@@ -0,0 +1,84 @@
+package dry
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type configTestConfig struct {
+	Name     string `default:"unnamed"`
+	Port     int
+	Debug    bool
+	Timeout  time.Duration
+	Tags     []string
+	Database struct {
+		Host     string `env:",required"`
+		MaxConns int
+	}
+}
+
+func TestConfigLoader(t *testing.T) {
+	dir := t.TempDir()
+	err := FileSetString(filepath.Join(dir, "config.json"), `{
+		"port": 8080,
+		"timeout": "3s",
+		"tags": ["a", "b"],
+		"database": {"host": "json-host", "max_conns": 5}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = FileSetString(filepath.Join(dir, "config.xml"), `<config><database><maxconns>7</maxconns></database><tags>x</tags><tags>y</tags></config>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = FileSetString(filepath.Join(dir, ".env"), "TEST_DATABASE_HOST=dotenv-host\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_PORT", "9090")
+
+	config := configTestConfig{Debug: false, Timeout: time.Second}
+	loader := ConfigLoader{
+		SearchDirs:  []string{filepath.Join(dir, "not-existing"), dir},
+		Filenames:   []string{"config.json", "config.xml", "missing.conf"},
+		DotenvFiles: []string{".env"},
+		Env:         true,
+		EnvPrefix:   "TEST_",
+		Args:        []string{"-debug", "-database.max-conns", "10"},
+	}
+	sources, err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "unnamed" ||
+		config.Port != 9090 ||
+		!config.Debug ||
+		config.Timeout != 3*time.Second ||
+		len(config.Tags) != 2 || config.Tags[0] != "x" ||
+		config.Database.Host != "dotenv-host" ||
+		config.Database.MaxConns != 10 {
+		t.Fatalf("Invalid values: %#v", config)
+	}
+	expectedSources := ConfigSources{
+		"Name":              "default",
+		"Port":              "env:TEST_PORT",
+		"Debug":             "flag:-debug",
+		"Timeout":           "file:" + filepath.Join(dir, "config.json"),
+		"Tags":              "file:" + filepath.Join(dir, "config.xml"),
+		"Database.Host":     "dotenv:" + filepath.Join(dir, ".env"),
+		"Database.MaxConns": "flag:-database.max-conns",
+	}
+	for field, source := range expectedSources {
+		if sources[field] != source {
+			t.Errorf("source of %s: expected %q, got %q", field, source, sources[field])
+		}
+	}
+
+	config = configTestConfig{}
+	_, err = (&ConfigLoader{Args: []string{"-port", "x"}}).Load(&config)
+	if err == nil {
+		t.Fatal("expected errors for invalid port and missing required field")
+	}
+}
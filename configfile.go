@@ -0,0 +1,219 @@
+package dry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+/*
+ConfigFile is an editable document model of a key=value config file
+as read by FileGetConfig.
+Comments, blank lines, the order of keys, the spacing around '='
+and the quoting style of values are retained, so writing back
+a ConfigFile only changes the lines of modified keys.
+The zero value is an empty config file.
+
+Usage example:
+
+	config, err := dry.FileGetConfigFile("service.conf")
+	if err != nil {
+		return err
+	}
+	config.Set("port", "8080")
+	config.Delete("legacy_option")
+	err = dry.FileSetConfigFile("service.conf", config)
+*/
+type ConfigFile struct {
+	lines []configFileLine
+	crlf  bool
+	// noTrailingNewline is true if the parsed file
+	// did not end with a line break
+	noTrailingNewline bool
+}
+
+type configFileLine struct {
+	// raw is the text of the line without line ending,
+	// it is written as is if the line is unchanged
+	raw string
+	// key is empty for comments, blank lines and lines without '='
+	key    string
+	value  string
+	quoted bool
+	// prefix is the text up to and including '=' plus
+	// whitespace before the value, used to rewrite changed values
+	prefix string
+}
+
+// ParseConfigFile parses data with the same rules as FileGetConfig.
+func ParseConfigFile(data []byte) *ConfigFile {
+	text := string(data)
+	c := &ConfigFile{
+		crlf:              strings.Contains(text, "\r\n"),
+		noTrailingNewline: text != "" && !strings.HasSuffix(text, "\n"),
+	}
+	if text == "" {
+		return c
+	}
+	text = strings.TrimSuffix(text, "\n")
+	for _, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		line := configFileLine{raw: raw}
+		if k, v, found := strings.Cut(raw, "="); found {
+			key := strings.TrimSpace(k)
+			if key != "" && key[0] != '#' {
+				line.key = key
+				value := strings.TrimSpace(v)
+				line.prefix = raw[:len(k)+1] + v[:len(v)-len(strings.TrimLeft(v, " \t"))]
+				if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+					value = value[1 : len(value)-1]
+					line.quoted = true
+				}
+				line.value = value
+			}
+		}
+		c.lines = append(c.lines, line)
+	}
+	return c
+}
+
+// FileGetConfigFile reads filenameOrURL as ConfigFile.
+func FileGetConfigFile(filenameOrURL string, timeout ...time.Duration) (*ConfigFile, error) {
+	data, err := FileGetBytes(filenameOrURL, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfigFile(data), nil
+}
+
+// FileSetConfigFile writes config to filename.
+func FileSetConfigFile(filename string, config *ConfigFile) error {
+	return FileSetBytes(filename, config.Bytes())
+}
+
+// Get returns the value of key.
+// If the key exists multiple times, the last value is returned
+// like FileGetConfig does.
+func (c *ConfigFile) Get(key string) (value string, ok bool) {
+	if i := c.lastIndex(key); i != -1 {
+		return c.lines[i].value, true
+	}
+	return "", false
+}
+
+// Has returns if key exists.
+func (c *ConfigFile) Has(key string) bool {
+	return c.lastIndex(key) != -1
+}
+
+// Set changes the value of an existing key in place,
+// or appends the key at the end if it does not exist yet.
+// The quoting style of an existing value is kept,
+// values with leading or trailing whitespace are always quoted.
+// Keys must not contain '=' or start with '#',
+// keys and values must not contain line breaks.
+func (c *ConfigFile) Set(key, value string) error {
+	switch {
+	case strings.TrimSpace(key) != key || key == "":
+		return fmt.Errorf("invalid config key '%s'", key)
+	case strings.ContainsRune(key, '=') || key[0] == '#':
+		return fmt.Errorf("config key '%s' contains '=' or starts with '#'", key)
+	case strings.ContainsAny(key, "\r\n") || strings.ContainsAny(value, "\r\n"):
+		return fmt.Errorf("config key '%s' or its value contains a line break", key)
+	}
+
+	i := c.lastIndex(key)
+	if i == -1 {
+		c.lines = append(c.lines, configFileLine{key: key, prefix: key + "="})
+		i = len(c.lines) - 1
+	} else if c.lines[i].value == value {
+		return nil
+	}
+	line := &c.lines[i]
+	line.value = value
+	line.quoted = line.quoted ||
+		strings.TrimSpace(value) != value ||
+		(len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"')
+	if line.quoted {
+		line.raw = line.prefix + `"` + value + `"`
+	} else {
+		line.raw = line.prefix + value
+	}
+	return nil
+}
+
+// Delete removes all lines with key and returns if any existed.
+func (c *ConfigFile) Delete(key string) bool {
+	lines := c.lines[:0]
+	for _, line := range c.lines {
+		if line.key != key {
+			lines = append(lines, line)
+		}
+	}
+	deleted := len(lines) != len(c.lines)
+	c.lines = lines
+	return deleted
+}
+
+// Keys returns the unique keys in the order of their first occurrence.
+func (c *ConfigFile) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, line := range c.lines {
+		if line.key != "" && !seen[line.key] {
+			seen[line.key] = true
+			keys = append(keys, line.key)
+		}
+	}
+	return keys
+}
+
+// Map returns the keys and values like FileGetConfig.
+func (c *ConfigFile) Map() map[string]string {
+	m := make(map[string]string, len(c.lines))
+	for _, line := range c.lines {
+		if line.key != "" {
+			m[line.key] = line.value
+		}
+	}
+	return m
+}
+
+// Bytes returns the text of the config file.
+func (c *ConfigFile) Bytes() []byte {
+	var buf bytes.Buffer
+	c.WriteTo(&buf) //#nosec G104 -- bytes.Buffer does not return errors
+	return buf.Bytes()
+}
+
+func (c *ConfigFile) String() string {
+	return string(c.Bytes())
+}
+
+// WriteTo writes the text of the config file to writer.
+func (c *ConfigFile) WriteTo(writer io.Writer) (n int64, err error) {
+	newline := "\n"
+	if c.crlf {
+		newline = "\r\n"
+	}
+	var b strings.Builder
+	for i, line := range c.lines {
+		b.WriteString(line.raw)
+		if i < len(c.lines)-1 || !c.noTrailingNewline {
+			b.WriteString(newline)
+		}
+	}
+	m, err := io.WriteString(writer, b.String())
+	return int64(m), err
+}
+
+func (c *ConfigFile) lastIndex(key string) int {
+	for i := len(c.lines) - 1; i >= 0; i-- {
+		if c.lines[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,48 @@
+package dry
+
+import "testing"
+
+func TestConfigFile(t *testing.T) {
+	const data = "# Service config\r\n\r\nname = \"my service\"\r\nport=80\r\nlegacy=1\r\n# trailing comment\r\n"
+	config := ParseConfigFile([]byte(data))
+
+	if value, ok := config.Get("name"); !ok || value != "my service" {
+		t.Fatalf("invalid value for name: %q", value)
+	}
+	if keys := config.Keys(); len(keys) != 3 || keys[0] != "name" || keys[2] != "legacy" {
+		t.Fatalf("invalid keys: %#v", keys)
+	}
+	if config.String() != data {
+		t.Fatalf("unchanged config not written identical: %q", config.String())
+	}
+
+	err := config.Set("name", "renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.Set("port", "8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.Set("host", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Delete("legacy") {
+		t.Fatal("legacy not deleted")
+	}
+	if err = config.Set("invalid=key", ""); err == nil {
+		t.Fatal("expected error for key with '='")
+	}
+
+	expected := "# Service config\r\n\r\nname = \"renamed\"\r\nport=8080\r\n# trailing comment\r\nhost=localhost\r\n"
+	if config.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, config.String())
+	}
+
+	var empty ConfigFile
+	empty.Set("a", " padded ")
+	if empty.String() != "a=\" padded \"\n" {
+		t.Fatalf("invalid new config: %q", empty.String())
+	}
+}
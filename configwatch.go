@@ -0,0 +1,271 @@
+package dry
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConfigWatcherOptions configures NewConfigWatcher.
+// A nil *ConfigWatcherOptions is valid and uses the defaults.
+type ConfigWatcherOptions struct {
+	// SearchDirs are used to find the file via FileFindModified,
+	// if empty the filename is used as is
+	SearchDirs []string
+	// PollInterval for checking the modification time and size
+	// of the file, defaults to one second
+	PollInterval time.Duration
+	// Debounce delays re-parsing after a detected change until
+	// no further change happened for this duration, defaults to 100ms
+	Debounce time.Duration
+	// Inotify additionally uses inotify on Linux to detect changes
+	// immediately, polling continues as fallback.
+	// Ignored on other systems.
+	Inotify bool
+	// Parse parses the file, defaults to FileGetConfig
+	Parse func(filename string) (map[string]string, error)
+}
+
+// ConfigChange is passed to the subscribers of a ConfigWatcher.
+// If Err is not nil, then the file could not be parsed and
+// the current config stays unchanged, with Old and New being identical.
+type ConfigChange struct {
+	Filename string
+	Old      map[string]string
+	New      map[string]string
+	Err      error
+}
+
+/*
+ConfigWatcher holds a parsed config file and re-parses it when it changes.
+The current config is swapped atomically and subscribers
+are notified with the old and new values.
+
+Usage example:
+
+	watcher, err := dry.NewConfigWatcher("service.conf", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer watcher.Close()
+	watcher.Subscribe(func(change dry.ConfigChange) {
+		if change.Err != nil {
+			log.Println("invalid config:", change.Err)
+		}
+	})
+	port := watcher.Value("port")
+*/
+type ConfigWatcher struct {
+	filename string
+	opts     ConfigWatcherOptions
+	config   atomic.Pointer[map[string]string]
+
+	subscribersMutex sync.Mutex
+	subscribers      map[int]func(ConfigChange)
+	nextSubscriber   int
+
+	// reloadMutex serializes reloads from Reload and the watching goroutine
+	reloadMutex sync.Mutex
+
+	trigger   chan struct{}
+	close     chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewConfigWatcher parses filename and starts watching it for changes.
+// An error is returned if the initial parsing fails.
+func NewConfigWatcher(filename string, opts *ConfigWatcherOptions) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{
+		filename:    filename,
+		subscribers: make(map[int]func(ConfigChange)),
+		trigger:     make(chan struct{}, 1),
+		close:       make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.PollInterval <= 0 {
+		w.opts.PollInterval = time.Second
+	}
+	if w.opts.Debounce <= 0 {
+		w.opts.Debounce = 100 * time.Millisecond
+	}
+	if w.opts.Parse == nil {
+		w.opts.Parse = func(filename string) (map[string]string, error) {
+			return FileGetConfig(filename)
+		}
+	}
+
+	path, modified, size := w.stat()
+	if path == "" {
+		return nil, &os.PathError{Op: "find", Path: filename, Err: os.ErrNotExist}
+	}
+	config, err := w.opts.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	w.config.Store(&config)
+
+	var stopInotify func()
+	if w.opts.Inotify {
+		stopInotify, err = configWatchInotify(path, w.notifyTrigger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	go w.run(path, modified, size, stopInotify)
+	return w, nil
+}
+
+// Filename returns the watched filename as passed to NewConfigWatcher.
+func (w *ConfigWatcher) Filename() string {
+	return w.filename
+}
+
+// Get returns the current config.
+// The returned map must not be modified.
+func (w *ConfigWatcher) Get() map[string]string {
+	return *w.config.Load()
+}
+
+// Value returns the current value for key.
+func (w *ConfigWatcher) Value(key string) string {
+	return w.Get()[key]
+}
+
+// Subscribe registers callback to be called for every change
+// of the config file and returns a function to unsubscribe.
+// Callbacks are called sequentially from the watching goroutine
+// or the goroutine calling Reload, and must not call Reload.
+func (w *ConfigWatcher) Subscribe(callback func(ConfigChange)) (unsubscribe func()) {
+	w.subscribersMutex.Lock()
+	defer w.subscribersMutex.Unlock()
+	id := w.nextSubscriber
+	w.nextSubscriber++
+	w.subscribers[id] = callback
+	return func() {
+		w.subscribersMutex.Lock()
+		delete(w.subscribers, id)
+		w.subscribersMutex.Unlock()
+	}
+}
+
+// Reload re-parses the file immediately
+// and notifies subscribers if the config changed.
+func (w *ConfigWatcher) Reload() error {
+	path, _, _ := w.stat()
+	return w.reload(path)
+}
+
+// Close stops watching the file.
+func (w *ConfigWatcher) Close() error {
+	err := errors.New("ConfigWatcher already closed")
+	w.closeOnce.Do(func() {
+		close(w.close)
+		err = nil
+	})
+	<-w.done
+	return err
+}
+
+func (w *ConfigWatcher) stat() (path string, modified time.Time, size int64) {
+	if len(w.opts.SearchDirs) == 0 {
+		path = w.filename
+		modified = FileTimeModified(path)
+		if modified.IsZero() {
+			return "", modified, 0
+		}
+	} else {
+		var found bool
+		path, found, modified = FileFindModified(w.opts.SearchDirs, w.filename)
+		if !found {
+			return "", modified, 0
+		}
+	}
+	return path, modified, FileSize(path)
+}
+
+func (w *ConfigWatcher) notifyTrigger() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (w *ConfigWatcher) run(path string, modified time.Time, size int64, stopInotify func()) {
+	defer close(w.done)
+	if stopInotify != nil {
+		defer stopInotify()
+	}
+
+	poll := time.NewTicker(w.opts.PollInterval)
+	defer poll.Stop()
+	debounce := time.NewTimer(w.opts.Debounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-w.close:
+			debounce.Stop()
+			return
+
+		case <-poll.C:
+			newPath, newModified, newSize := w.stat()
+			if newPath != path || !newModified.Equal(modified) || newSize != size {
+				path, modified, size = newPath, newModified, newSize
+				debounce.Reset(w.opts.Debounce)
+			}
+
+		case <-w.trigger:
+			debounce.Reset(w.opts.Debounce)
+
+		case <-debounce.C:
+			path, modified, size = w.stat()
+			w.reload(path) //#nosec G104 -- errors are passed to subscribers
+		}
+	}
+}
+
+func (w *ConfigWatcher) reload(path string) error {
+	// Locked until the subscribers are notified so that
+	// a slower parse can't overwrite a newer config
+	// and changes are passed in order
+	w.reloadMutex.Lock()
+	defer w.reloadMutex.Unlock()
+
+	old := w.Get()
+	change := ConfigChange{Filename: path, Old: old, New: old}
+	if path == "" {
+		change.Err = &os.PathError{Op: "find", Path: w.filename, Err: os.ErrNotExist}
+	} else {
+		config, err := w.opts.Parse(path)
+		switch {
+		case err != nil:
+			change.Err = err
+		case maps.Equal(config, old):
+			return nil
+		default:
+			w.config.Store(&config)
+			change.New = config
+		}
+	}
+
+	w.subscribersMutex.Lock()
+	callbacks := make([]func(ConfigChange), 0, len(w.subscribers))
+	for id := 0; id < w.nextSubscriber; id++ {
+		if callback, ok := w.subscribers[id]; ok {
+			callbacks = append(callbacks, callback)
+		}
+	}
+	w.subscribersMutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback(change)
+	}
+	return change.Err
+}
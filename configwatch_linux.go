@@ -0,0 +1,63 @@
+//go:build linux
+
+package dry
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// configWatchInotify calls onChange for every inotify event
+// concerning the base name of filename in its directory.
+// Watching the directory instead of the file also catches
+// editors that replace files by renaming.
+func configWatchInotify(filename string, onChange func()) (stop func(), err error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	dir, name := filepath.Split(filepath.Clean(filename))
+	if dir == "" {
+		dir = "."
+	}
+	const mask = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE
+	_, err = syscall.InotifyAddWatch(fd, dir, mask)
+	if err != nil {
+		syscall.Close(fd) //#nosec G104
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	// The non-blocking descriptor uses the runtime poller,
+	// so closing the file interrupts the blocking Read
+	file := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset])) //#nosec G103
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+				if inotifyEventName(nameBytes) == name {
+					onChange()
+				}
+			}
+		}
+	}()
+	return func() { file.Close() }, nil //#nosec G104
+}
+
+// inotifyEventName returns the NUL padded name of an inotify event.
+func inotifyEventName(name []byte) string {
+	for i, c := range name {
+		if c == 0 {
+			return string(name[:i])
+		}
+	}
+	return string(name)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package dry
+
+// configWatchInotify is a no-op on systems without inotify,
+// ConfigWatcher falls back to polling.
+func configWatchInotify(filename string, onChange func()) (stop func(), err error) {
+	return nil, nil
+}
@@ -0,0 +1,112 @@
+package dry
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.conf")
+	err := FileSetString(filename, "a=1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inotify := range []bool{false, true} {
+		watcher, err := NewConfigWatcher(filename, &ConfigWatcherOptions{
+			PollInterval: 10 * time.Millisecond,
+			Debounce:     10 * time.Millisecond,
+			Inotify:      inotify,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes := make(chan ConfigChange, 10)
+		watcher.Subscribe(func(change ConfigChange) { changes <- change })
+
+		old := watcher.Value("a")
+		err = FileSetString(filename, "a="+old+"0\nb=2\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case change := <-changes:
+			if change.Err != nil {
+				t.Fatal(change.Err)
+			}
+			if change.Old["a"] != old || change.New["a"] != old+"0" || change.New["b"] != "2" {
+				t.Fatalf("invalid change: %#v", change)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change notification")
+		}
+		if watcher.Value("b") != "2" {
+			t.Fatalf("config not swapped: %#v", watcher.Get())
+		}
+		err = watcher.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConfigWatcherConcurrentReload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.conf")
+	err := FileSetString(filename, "a=1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parses atomic.Int64
+	watcher, err := NewConfigWatcher(filename, &ConfigWatcherOptions{
+		Parse: func(filename string) (map[string]string, error) {
+			n := parses.Add(1)
+			if n == 2 {
+				// The first reload is slower than the following ones
+				time.Sleep(50 * time.Millisecond)
+			}
+			return map[string]string{"n": strconv.FormatInt(n, 10)}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []ConfigChange
+	watcher.Subscribe(func(change ConfigChange) { changes = append(changes, change) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watcher.Reload()
+		}()
+		if i == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	wg.Wait()
+	if len(changes) != 5 {
+		t.Fatalf("expected 5 changes, got %d", len(changes))
+	}
+	for i := 1; i < len(changes); i++ {
+		if changes[i].Old["n"] != changes[i-1].New["n"] {
+			t.Errorf("change %d out of order: %v after %v", i, changes[i], changes[i-1])
+		}
+	}
+	if watcher.Value("n") != changes[4].New["n"] {
+		t.Errorf("newer config overwritten by %v", watcher.Get())
+	}
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- watcher.Close() }()
+	}
+	if err1, err2 := <-errs, <-errs; (err1 == nil) == (err2 == nil) {
+		t.Errorf("expected exactly one Close to fail: %v, %v", err1, err2)
+	}
+}
@@ -0,0 +1,316 @@
+package dry
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CopyDirExisting is the policy of CopyDir for an existing destination.
+type CopyDirExisting int
+
+const (
+	// CopyDirFail returns an error if the destination directory exists.
+	CopyDirFail CopyDirExisting = iota
+	// CopyDirMerge copies into an existing destination directory
+	// and replaces existing files only if the source file is newer.
+	CopyDirMerge
+	// CopyDirOverwrite copies into an existing destination directory
+	// and replaces all existing files.
+	CopyDirOverwrite
+	// CopyDirSkipExisting copies into an existing destination directory
+	// and keeps all existing files.
+	CopyDirSkipExisting
+)
+
+// CopyDirOptions configures CopyDir.
+// A nil *CopyDirOptions is valid and uses the defaults.
+type CopyDirOptions struct {
+	// Existing is the policy for an existing destination,
+	// defaults to CopyDirFail
+	Existing CopyDirExisting
+	// Include limits the copied files to the ones matching at least
+	// one pattern, see WalkDirOptions for the pattern syntax.
+	// Only directories containing included files are created.
+	Include []string
+	// Exclude skips matching files and directories
+	Exclude []string
+	// Symlinks is the policy for symbolic links, defaults to
+	// WalkDirSymlinksReport which re-creates the links at the destination.
+	// WalkDirSymlinksFollow copies the link targets instead.
+	Symlinks WalkDirSymlinks
+	// PreserveTimes sets the modification times of copied
+	// files and directories to the ones of the source.
+	// Permissions are always preserved.
+	PreserveTimes bool
+	// Parallel is the number of files copied concurrently,
+	// defaults to 1
+	Parallel int
+	// Progress is called after every written chunk and every
+	// finished file, calls are serialized
+	Progress func(CopyDirProgress)
+}
+
+// CopyDirProgress is passed to CopyDirOptions.Progress.
+type CopyDirProgress struct {
+	// RelPath is the '/' separated path of the file relative
+	// to the source directory that was just written
+	RelPath    string
+	Files      int
+	TotalFiles int
+	Bytes      int64
+	TotalBytes int64
+}
+
+/*
+CopyDir recursively copies the directory tree source to dest.
+Failures of individual files don't stop the copying
+and are returned together as ErrorList.
+
+Usage example:
+
+	err := dry.CopyDir("assets", "dist/assets", &dry.CopyDirOptions{
+		Existing:      dry.CopyDirMerge,
+		Exclude:       []string{"*.psd"},
+		PreserveTimes: true,
+		Parallel:      4,
+	})
+*/
+func CopyDir(source, dest string, opts *CopyDirOptions) error {
+	if opts == nil {
+		opts = &CopyDirOptions{}
+	}
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if !sourceInfo.IsDir() {
+		return &FileCopyError{"Source is not a directory"}
+	}
+	if opts.Existing == CopyDirFail {
+		if _, err := os.Lstat(dest); !os.IsNotExist(err) {
+			return &FileCopyError{"Destination already exists"}
+		}
+	}
+
+	c := &dirCopier{opts: opts, source: source, dest: dest}
+	c.plan(sourceInfo)
+
+	for _, dir := range c.dirs {
+		err = os.MkdirAll(dir.destPath, dir.info.Mode().Perm()|0o700)
+		if err != nil {
+			c.errs = append(c.errs, err)
+		}
+	}
+
+	parallel := max(opts.Parallel, 1)
+	jobs := make(chan *copyDirFile)
+	var wg sync.WaitGroup
+	for range parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				c.copy(file)
+			}
+		}()
+	}
+	for i := range c.files {
+		jobs <- &c.files[i]
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Set directory permissions and times after their content
+	// was written, deepest first
+	for i := len(c.dirs) - 1; i >= 0; i-- {
+		dir := c.dirs[i]
+		err = os.Chmod(dir.destPath, dir.info.Mode().Perm())
+		if err == nil && opts.PreserveTimes {
+			err = os.Chtimes(dir.destPath, dir.info.ModTime(), dir.info.ModTime())
+		}
+		if err != nil {
+			c.errs = append(c.errs, err)
+		}
+	}
+	return c.errs.Err()
+}
+
+type copyDirFile struct {
+	relPath  string
+	destPath string
+	info     fs.FileInfo
+}
+
+type dirCopier struct {
+	opts   *CopyDirOptions
+	source string
+	dest   string
+	dirs   []copyDirFile
+	files  []copyDirFile
+
+	mutex      sync.Mutex
+	errs       ErrorList
+	totalBytes int64
+	bytes      int64
+	filesDone  int
+}
+
+func (c *dirCopier) plan(sourceInfo fs.FileInfo) {
+	c.dirs = append(c.dirs, copyDirFile{destPath: c.dest, info: sourceInfo})
+	walkOpts := &WalkDirOptions{
+		Exclude:  c.opts.Exclude,
+		Symlinks: c.opts.Symlinks,
+		Dirs:     true,
+	}
+	var dirs []copyDirFile
+	for entry, err := range WalkDir(c.source, walkOpts) {
+		if err != nil {
+			c.errs = append(c.errs, err)
+			continue
+		}
+		file := copyDirFile{
+			relPath:  entry.RelPath,
+			destPath: filepath.Join(c.dest, filepath.FromSlash(entry.RelPath)),
+			info:     entry.Info,
+		}
+		switch {
+		case entry.Info.IsDir():
+			dirs = append(dirs, file)
+		case len(c.opts.Include) == 0 || walkMatchAny(c.opts.Include, entry.RelPath):
+			c.files = append(c.files, file)
+			if entry.Info.Mode().IsRegular() {
+				c.totalBytes += entry.Info.Size()
+			}
+		}
+	}
+	for _, dir := range dirs {
+		if len(c.opts.Include) == 0 || c.dirHasFiles(dir.relPath) {
+			c.dirs = append(c.dirs, dir)
+		}
+	}
+}
+
+func (c *dirCopier) dirHasFiles(relDir string) bool {
+	for _, file := range c.files {
+		if strings.HasPrefix(file.relPath, relDir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *dirCopier) copy(file *copyDirFile) {
+	var err error
+	if file.info.Mode()&fs.ModeSymlink != 0 {
+		err = c.copySymlink(file)
+	} else {
+		err = c.copyFile(file)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err != nil {
+		c.errs = append(c.errs, fmt.Errorf("can't copy %s: %w", file.relPath, err))
+	}
+	c.filesDone++
+	c.progress(file.relPath)
+}
+
+func (c *dirCopier) copyFile(file *copyDirFile) error {
+	if destInfo, err := os.Lstat(file.destPath); err == nil {
+		switch c.opts.Existing {
+		case CopyDirSkipExisting:
+			c.addBytes(file.relPath, file.info.Size())
+			return nil
+		case CopyDirMerge:
+			if !file.info.ModTime().After(destInfo.ModTime()) {
+				c.addBytes(file.relPath, file.info.Size())
+				return nil
+			}
+		}
+		if destInfo.Mode()&fs.ModeSymlink != 0 {
+			err = os.Remove(file.destPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	sourceFile, err := os.Open(filepath.Join(c.source, filepath.FromSlash(file.relPath))) //#nosec G304
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close() //#nosec G307
+
+	destFile, err := os.OpenFile(file.destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.info.Mode().Perm()) //#nosec G304
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(destFile, io.TeeReader(sourceFile, WriterFunc(func(p []byte) (int, error) {
+		c.addBytes(file.relPath, int64(len(p)))
+		return len(p), nil
+	})))
+	if closeErr := destFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	// Chmod because OpenFile does not change the permissions
+	// of existing files and applies the umask to new files
+	err = os.Chmod(file.destPath, file.info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if c.opts.PreserveTimes {
+		return os.Chtimes(file.destPath, file.info.ModTime(), file.info.ModTime())
+	}
+	return nil
+}
+
+func (c *dirCopier) copySymlink(file *copyDirFile) error {
+	target, err := os.Readlink(filepath.Join(c.source, filepath.FromSlash(file.relPath)))
+	if err != nil {
+		return err
+	}
+	if destInfo, err := os.Lstat(file.destPath); err == nil {
+		if c.opts.Existing == CopyDirSkipExisting {
+			return nil
+		}
+		if destInfo.Mode()&fs.ModeSymlink != 0 {
+			if existing, _ := os.Readlink(file.destPath); existing == target {
+				return nil
+			}
+		}
+		err = os.Remove(file.destPath)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Symlink(target, file.destPath)
+}
+
+func (c *dirCopier) addBytes(relPath string, n int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.bytes += n
+	c.progress(relPath)
+}
+
+// progress must be called with locked mutex
+func (c *dirCopier) progress(relPath string) {
+	if c.opts.Progress == nil {
+		return
+	}
+	c.opts.Progress(CopyDirProgress{
+		RelPath:    relPath,
+		Files:      c.filesDone,
+		TotalFiles: len(c.files),
+		Bytes:      c.bytes,
+		TotalBytes: c.totalBytes,
+	})
+}
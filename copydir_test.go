@@ -0,0 +1,103 @@
+package dry
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCopyDir(t *testing.T) {
+	source := walkDirTestTree(t)
+	FileSetString(filepath.Join(source, "main.go"), "package main")
+	os.Chmod(filepath.Join(source, "main.go"), 0o600)
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	os.Chtimes(filepath.Join(source, "main.go"), modified, modified)
+	symlinks := os.Symlink("main.go", filepath.Join(source, "link.go")) == nil
+
+	dest := filepath.Join(t.TempDir(), "dest")
+	var lastProgress atomic.Pointer[CopyDirProgress]
+	err := CopyDir(source, dest, &CopyDirOptions{
+		Exclude:       []string{"vendor", "*.log"},
+		PreserveTimes: true,
+		Parallel:      3,
+		Progress: func(progress CopyDirProgress) {
+			lastProgress.Store(&progress)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := FileGetString(filepath.Join(dest, "main.go")); data != "package main" {
+		t.Errorf("invalid content: %q", data)
+	}
+	info, err := os.Stat(filepath.Join(dest, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 || !info.ModTime().Equal(modified) {
+		t.Errorf("permissions or time not preserved: %s %s", info.Mode(), info.ModTime())
+	}
+	if FileExists(filepath.Join(dest, "vendor")) || FileExists(filepath.Join(dest, "logs", "app.log")) {
+		t.Error("excluded files copied")
+	}
+	if !FileIsDir(filepath.Join(dest, "logs")) || !FileExists(filepath.Join(dest, "cmd", "tool", "tool.go")) {
+		t.Error("missing copied files")
+	}
+	if symlinks {
+		if target, err := os.Readlink(filepath.Join(dest, "link.go")); target != "main.go" {
+			t.Errorf("symlink not preserved: %q, %v", target, err)
+		}
+	}
+	progress := lastProgress.Load()
+	if progress == nil || progress.Files != progress.TotalFiles || progress.Bytes != progress.TotalBytes || progress.TotalBytes == 0 {
+		t.Errorf("invalid progress: %+v", progress)
+	}
+
+	err = CopyDir(source, dest, nil)
+	if _, ok := err.(*FileCopyError); !ok {
+		t.Errorf("expected *FileCopyError for existing destination, got %v", err)
+	}
+}
+
+func TestCopyDirExisting(t *testing.T) {
+	source := t.TempDir()
+	FileSetString(filepath.Join(source, "old.txt"), "source")
+	FileSetString(filepath.Join(source, "new.txt"), "source")
+	os.Mkdir(filepath.Join(source, "sub"), 0o755)
+	FileSetString(filepath.Join(source, "sub", "skip.md"), "")
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(source, "old.txt"), past, past)
+
+	tests := []struct {
+		existing CopyDirExisting
+		old      string
+		new      string
+	}{
+		{CopyDirMerge, "dest", "source"},
+		{CopyDirOverwrite, "source", "source"},
+		{CopyDirSkipExisting, "dest", "dest"},
+	}
+	for _, test := range tests {
+		dest := t.TempDir()
+		FileSetString(filepath.Join(dest, "old.txt"), "dest")
+		FileSetString(filepath.Join(dest, "new.txt"), "dest")
+		os.Chtimes(filepath.Join(dest, "new.txt"), past, past)
+
+		err := CopyDir(source, dest, &CopyDirOptions{Existing: test.existing, Include: []string{"*.txt"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := FileGetString(filepath.Join(dest, "old.txt")); data != test.old {
+			t.Errorf("policy %d: old.txt is %q, expected %q", test.existing, data, test.old)
+		}
+		if data, _ := FileGetString(filepath.Join(dest, "new.txt")); data != test.new {
+			t.Errorf("policy %d: new.txt is %q, expected %q", test.existing, data, test.new)
+		}
+		if FileExists(filepath.Join(dest, "sub")) {
+			t.Errorf("policy %d: created directory without included files", test.existing)
+		}
+	}
+}
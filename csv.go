@@ -0,0 +1,313 @@
+package dry
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// CSVOptions configures the CSV struct mapping functions.
+// A nil *CSVOptions is valid and uses the defaults.
+type CSVOptions struct {
+	// Comma is the field delimiter, defaults to ','
+	Comma rune
+	// Comment starts lines that are ignored when reading,
+	// zero disables comments
+	Comment rune
+	// TimeFormat is used for time.Time fields if not empty,
+	// else the RFC 3339 text marshalling of time.Time is used
+	TimeFormat string
+}
+
+// CSVError describes a problem with a cell of a CSV file.
+type CSVError struct {
+	// Line of the cell, starting at 1
+	Line int
+	// Column of the cell, starting at 1
+	Column int
+	// BytePos of the cell within its line, starting at 1,
+	// zero for errors of writing CSV
+	BytePos int
+	Header  string
+	Err     error
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("CSV line %d column %d (%s): %s", e.Line, e.Column, e.Header, e.Err)
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ReadCSVStructs reads CSV with a header row from reader and maps
+the columns to the fields of T, which must be a struct or pointer to a struct.
+The column header of a field is the name from its `csv` tag or the Go field name,
+headers are matched exactly first and then case insensitive.
+Fields tagged with `csv:"-"`, unexported fields and columns without
+matching field are ignored.
+Anonymous embedded structs are inlined, other nested structs
+use their header plus "." as prefix like "Address.City".
+Empty cells leave the field at its zero value.
+
+Besides the basic kinds, time.Time, time.Duration, url.URL and all types
+implementing encoding.TextUnmarshaler are supported.
+Slices are split by the separator from the `sep` tag or ",".
+
+Cell conversion errors are returned as *CSVError in an ErrorList
+together with all rows, where the problematic fields are left zero.
+
+Usage example:
+
+	type Person struct {
+		Name     string    `csv:"name"`
+		Age      int       `csv:"age"`
+		Birthday time.Time `csv:"birthday"`
+	}
+	people, err := dry.FileGetCSVStructs[Person]("people.csv", &dry.CSVOptions{
+		Comma:      ';',
+		Comment:    '#',
+		TimeFormat: time.DateOnly,
+	})
+*/
+func ReadCSVStructs[T any](reader io.Reader, opts *CSVOptions) ([]T, error) {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+	structType, isPtr, err := csvStructType(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	fields := csvStructFields(structType, "", nil, nil)
+
+	csvReader := csv.NewReader(reader)
+	if opts.Comma != 0 {
+		csvReader.Comma = opts.Comma
+	}
+	csvReader.Comment = opts.Comment
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]*csvField, len(header))
+	for col, name := range header {
+		if col == 0 {
+			name = strings.TrimPrefix(name, "\uFEFF") // UTF-8 BOM
+		}
+		columns[col] = csvFindField(fields, strings.TrimSpace(name))
+	}
+
+	var (
+		rows []T
+		errs ErrorList
+	)
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			return rows, errs
+		}
+
+		var row T
+		var v reflect.Value
+		if isPtr {
+			ptr := reflect.New(structType)
+			row = ptr.Interface().(T)
+			v = ptr.Elem()
+		} else {
+			v = reflect.ValueOf(&row).Elem()
+		}
+		for col, cell := range record {
+			if col >= len(columns) || columns[col] == nil || cell == "" {
+				continue
+			}
+			err = columns[col].set(v.FieldByIndex(columns[col].index), cell, opts)
+			if err != nil {
+				line, bytePos := csvReader.FieldPos(col)
+				errs = append(errs, &CSVError{Line: line, Column: col + 1, BytePos: bytePos, Header: header[col], Err: err})
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, errs.Err()
+}
+
+// WriteCSVStructs writes rows as CSV with a header row to writer.
+// See ReadCSVStructs for the mapping of struct fields to columns.
+// Nil pointers in rows are written as empty cells.
+func WriteCSVStructs[T any](writer io.Writer, rows []T, opts *CSVOptions) error {
+	return csvWriteStructs(writer, reflect.ValueOf(rows), opts)
+}
+
+// csvWriteStructs implements WriteCSVStructs for a reflected slice
+func csvWriteStructs(writer io.Writer, rows reflect.Value, opts *CSVOptions) error {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+	structType, isPtr, err := csvStructType(rows.Type().Elem())
+	if err != nil {
+		return err
+	}
+	fields := csvStructFields(structType, "", nil, nil)
+
+	csvWriter := csv.NewWriter(writer)
+	if opts.Comma != 0 {
+		csvWriter.Comma = opts.Comma
+	}
+	record := make([]string, len(fields))
+	for i := range fields {
+		record[i] = fields[i].header
+	}
+	err = csvWriter.Write(record)
+	if err != nil {
+		return err
+	}
+
+	for rowIndex := 0; rowIndex < rows.Len(); rowIndex++ {
+		v := rows.Index(rowIndex)
+		if isPtr {
+			if v.IsNil() {
+				clear(record)
+				err = csvWriter.Write(record)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			v = v.Elem()
+		}
+		for i := range fields {
+			record[i], err = fields[i].format(v.FieldByIndex(fields[i].index), opts)
+			if err != nil {
+				return &CSVError{Line: rowIndex + 2, Column: i + 1, Header: fields[i].header, Err: err}
+			}
+		}
+		err = csvWriter.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// FileGetCSVStructs reads a CSV file with a header row
+// and maps its rows to T, see ReadCSVStructs.
+func FileGetCSVStructs[T any](filenameOrURL string, opts *CSVOptions, timeout ...time.Duration) ([]T, error) {
+	data, err := FileGetBytes(filenameOrURL, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	return ReadCSVStructs[T](bytes.NewReader(data), opts)
+}
+
+// FileSetCSVStructs writes rows as CSV file with a header row,
+// see WriteCSVStructs.
+func FileSetCSVStructs[T any](filename string, rows []T, opts *CSVOptions) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //#nosec G307
+	return WriteCSVStructs(file, rows, opts)
+}
+
+func csvStructType(rowType reflect.Type) (structType reflect.Type, isPtr bool, err error) {
+	structType = rowType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+		isPtr = true
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, false, fmt.Errorf("CSV rows must be structs or pointers to structs, but are %s", rowType)
+	}
+	return structType, isPtr, nil
+}
+
+type csvField struct {
+	header string
+	index  []int
+	sep    string
+}
+
+func csvStructFields(t reflect.Type, prefix string, index []int, fields []csvField) []csvField {
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !ReflectStructFieldIsExported(structField) && !(structField.Anonymous && structField.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		tag, _, _ := strings.Cut(structField.Tag.Get("csv"), ",")
+		if tag == "-" {
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		header := tag
+		if header == "" {
+			header = structField.Name
+		}
+		if reflectIsStructToRecurse(structField.Type) {
+			if structField.Anonymous && tag == "" {
+				fields = csvStructFields(structField.Type, prefix, fieldIndex, fields)
+			} else {
+				fields = csvStructFields(structField.Type, prefix+header+".", fieldIndex, fields)
+			}
+			continue
+		}
+		fields = append(fields, csvField{
+			header: prefix + header,
+			index:  fieldIndex,
+			sep:    structField.Tag.Get("sep"),
+		})
+	}
+	return fields
+}
+
+func csvFindField(fields []csvField, header string) *csvField {
+	for i := range fields {
+		if fields[i].header == header {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].header, header) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func (f *csvField) set(v reflect.Value, cell string, opts *CSVOptions) error {
+	if opts.TimeFormat != "" {
+		if t, ok := v.Addr().Interface().(*time.Time); ok {
+			parsed, err := time.Parse(opts.TimeFormat, cell)
+			if err != nil {
+				return err
+			}
+			*t = parsed
+			return nil
+		}
+	}
+	return reflectSetFromString(v, cell, f.sep)
+}
+
+func (f *csvField) format(v reflect.Value, opts *CSVOptions) (string, error) {
+	if opts.TimeFormat != "" {
+		if t, ok := v.Interface().(time.Time); ok {
+			return t.Format(opts.TimeFormat), nil
+		}
+	}
+	return reflectFormatString(v, f.sep)
+}
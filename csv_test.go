@@ -0,0 +1,121 @@
+package dry
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type csvTestBase struct {
+	ID int `csv:"id"`
+}
+
+type csvTestPerson struct {
+	csvTestBase
+	Name     string        `csv:"name"`
+	Birthday time.Time     `csv:"birthday"`
+	Tags     []string      `csv:"tags" sep:"|"`
+	Timeout  time.Duration `csv:"timeout"`
+	Address  struct {
+		City string
+	}
+	Ignored string `csv:"-"`
+}
+
+func TestReadCSVStructs(t *testing.T) {
+	data := "\uFEFFid;NAME;birthday;tags;unknown;Address.City\n" +
+		"# comment\n" +
+		"1;Alice;2001-02-03;a|b;x;Berlin\n" +
+		"2;\"Bob; Jr.\";;;;\n"
+	opts := &CSVOptions{Comma: ';', Comment: '#', TimeFormat: time.DateOnly}
+	people, err := ReadCSVStructs[csvTestPerson](strings.NewReader(data), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(people) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(people))
+	}
+	alice := people[0]
+	if alice.ID != 1 || alice.Name != "Alice" || alice.Address.City != "Berlin" {
+		t.Errorf("invalid row: %#v", alice)
+	}
+	if !alice.Birthday.Equal(time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("invalid birthday: %s", alice.Birthday)
+	}
+	if !reflect.DeepEqual(alice.Tags, []string{"a", "b"}) {
+		t.Errorf("invalid tags: %#v", alice.Tags)
+	}
+	if people[1].Name != "Bob; Jr." || !people[1].Birthday.IsZero() || people[1].Tags != nil {
+		t.Errorf("invalid row: %#v", people[1])
+	}
+
+	pointers, err := ReadCSVStructs[*csvTestPerson](strings.NewReader(data), opts)
+	if err != nil || len(pointers) != 2 || pointers[1].Name != "Bob; Jr." {
+		t.Errorf("invalid pointer rows: %v, %v", pointers, err)
+	}
+}
+
+func TestReadCSVStructsErrors(t *testing.T) {
+	data := "id,name,timeout\nx,Alice,1s\n2,Bob,never\n"
+	people, err := ReadCSVStructs[csvTestPerson](strings.NewReader(data), nil)
+	if len(people) != 2 || people[0].Name != "Alice" || people[1].ID != 2 {
+		t.Errorf("rows should be returned with errors: %#v", people)
+	}
+	errs := AsErrorList(err)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", err)
+	}
+	var csvErr *CSVError
+	if !errors.As(errs[1], &csvErr) {
+		t.Fatalf("expected *CSVError, got %T", errs[1])
+	}
+	if csvErr.Line != 3 || csvErr.Column != 3 || csvErr.BytePos != 7 || csvErr.Header != "timeout" {
+		t.Errorf("invalid error position: %s", csvErr)
+	}
+
+	_, err = ReadCSVStructs[int](strings.NewReader(data), nil)
+	if err == nil {
+		t.Error("expected error for non struct type")
+	}
+}
+
+func TestFileSetCSVStructs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "people.csv")
+	people := []csvTestPerson{
+		{
+			csvTestBase: csvTestBase{ID: 1},
+			Name:        "Alice, Jr.",
+			Birthday:    time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC),
+			Tags:        []string{"a", "b"},
+			Timeout:     time.Minute,
+			Ignored:     "ignored",
+		},
+		{Name: "Bob"},
+	}
+	people[0].Address.City = "Berlin"
+
+	err := FileSetCSVStructs(filename, people, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := FileGetString(filename)
+	lines := strings.Split(data, "\n")
+	if lines[0] != "id,name,birthday,tags,timeout,Address.City" {
+		t.Errorf("invalid header: %s", lines[0])
+	}
+	if lines[1] != `1,"Alice, Jr.",2001-02-03T04:05:06Z,a|b,1m0s,Berlin` {
+		t.Errorf("invalid row: %s", lines[1])
+	}
+
+	read, err := FileGetCSVStructs[csvTestPerson](filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	people[0].Ignored = ""
+	if !reflect.DeepEqual(read, people) {
+		t.Errorf("read %#v, expected %#v", read, people)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 	"runtime"
 	"strings"
-	"sync"
 )
 
 // PrettyPrintAsJSON marshals input as indented JSON
@@ -127,47 +126,3 @@ func function(pc uintptr) []byte {
 	name = bytes.Replace(name, centerDot, dot, -1)
 	return name
 }
-
-// DebugMutex wraps a sync.Mutex and adds debug output
-type DebugMutex struct {
-	m sync.Mutex
-}
-
-func (d *DebugMutex) Lock() {
-	fmt.Println("Mutex.Lock()\n" + StackTraceLine(3))
-	d.m.Lock()
-}
-
-func (d *DebugMutex) Unlock() {
-	fmt.Println("Mutex.Unlock()\n" + StackTraceLine(3))
-	d.m.Unlock()
-}
-
-// DebugRWMutex wraps a sync.RWMutex and adds debug output
-type DebugRWMutex struct {
-	m sync.RWMutex
-}
-
-func (d *DebugRWMutex) RLock() {
-	fmt.Println("RWMutex.RLock()\n" + StackTraceLine(3))
-	d.m.RLock()
-}
-
-func (d *DebugRWMutex) RUnlock() {
-	fmt.Println("RWMutex.RUnlock()\n" + StackTraceLine(3))
-	d.m.RUnlock()
-}
-
-func (d *DebugRWMutex) Lock() {
-	fmt.Println("RWMutex.Lock()\n" + StackTraceLine(3))
-	d.m.Lock()
-}
-
-func (d *DebugRWMutex) Unlock() {
-	fmt.Println("RWMutex.Unlock()\n" + StackTraceLine(3))
-	d.m.Unlock()
-}
-
-func (d *DebugRWMutex) RLocker() sync.Locker {
-	return d.m.RLocker()
-}
@@ -0,0 +1,470 @@
+package dry
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DebugLockHoldThreshold is the duration after which a DebugMutex
+	// or DebugRWMutex that is still locked gets reported.
+	// Zero disables hold reports.
+	DebugLockHoldThreshold = 5 * time.Second
+
+	// DebugLockWaitThreshold is the duration after which a goroutine
+	// that is still waiting to acquire a DebugMutex or DebugRWMutex
+	// gets reported.
+	// Zero disables wait reports.
+	DebugLockWaitThreshold = 5 * time.Second
+
+	// DebugLockReportFunc is called for every DebugLockReport.
+	// If nil, then the report is logged as warning with slog.Default().
+	// Set the threshold variables and DebugLockReportFunc before
+	// the first lock is used, they are not protected against concurrent changes.
+	DebugLockReportFunc func(report *DebugLockReport)
+)
+
+// DebugLockReportKind is the kind of a DebugLockReport.
+type DebugLockReportKind int
+
+const (
+	// DebugLockHeldTooLong reports a lock that is held longer than DebugLockHoldThreshold.
+	DebugLockHeldTooLong DebugLockReportKind = iota
+	// DebugLockWaitedTooLong reports a goroutine that waits longer than DebugLockWaitThreshold.
+	DebugLockWaitedTooLong
+	// DebugLockOrderInversion reports two named locks that
+	// have been acquired in different orders by different code paths.
+	DebugLockOrderInversion
+)
+
+func (k DebugLockReportKind) String() string {
+	switch k {
+	case DebugLockHeldTooLong:
+		return "lock held too long"
+	case DebugLockWaitedTooLong:
+		return "lock waited for too long"
+	case DebugLockOrderInversion:
+		return "lock order inversion"
+	}
+	return "DebugLockReportKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// DebugLockHolder describes a goroutine holding a DebugMutex or DebugRWMutex.
+type DebugLockHolder struct {
+	Name      string
+	Goroutine uint64
+	Exclusive bool
+	Since     time.Time
+	Stack     string
+}
+
+// DebugLockReport is passed to DebugLockReportFunc.
+type DebugLockReport struct {
+	Kind      DebugLockReportKind
+	Name      string
+	Goroutine uint64
+	Duration  time.Duration
+	Stack     string
+	// Holders of the lock at the time of the report
+	Holders []DebugLockHolder
+	// OtherName is the name of the other lock involved in a DebugLockOrderInversion
+	OtherName string
+	// OtherStack is the stack where the reverse lock order
+	// of a DebugLockOrderInversion was first observed
+	OtherStack string
+}
+
+func (r *DebugLockReport) String() string {
+	var b strings.Builder
+	switch r.Kind {
+	case DebugLockOrderInversion:
+		fmt.Fprintf(&b, "%s: goroutine %d locks %q while holding %q\n%s", r.Kind, r.Goroutine, r.Name, r.OtherName, r.Stack)
+		fmt.Fprintf(&b, "reverse order %q before %q was locked at:\n%s", r.Name, r.OtherName, r.OtherStack)
+	default:
+		fmt.Fprintf(&b, "%s: %q for %s by goroutine %d\n%s", r.Kind, r.Name, r.Duration, r.Goroutine, r.Stack)
+	}
+	for _, h := range r.Holders {
+		fmt.Fprintf(&b, "held by goroutine %d since %s:\n%s", h.Goroutine, h.Since.Format(time.RFC3339Nano), h.Stack)
+	}
+	return b.String()
+}
+
+// DebugLockStats holds contention statistics of a DebugMutex or DebugRWMutex.
+type DebugLockStats struct {
+	Acquisitions uint64
+	// Contended counts acquisitions that had to wait for another holder
+	Contended uint64
+	TotalWait time.Duration
+	MaxWait   time.Duration
+	TotalHold time.Duration
+	MaxHold   time.Duration
+}
+
+// DebugLockHolders returns all currently held
+// DebugMutex and DebugRWMutex locks sorted by acquisition time.
+func DebugLockHolders() []DebugLockHolder {
+	debugLocks.mutex.Lock()
+	defer debugLocks.mutex.Unlock()
+
+	var result []DebugLockHolder
+	for state := range debugLocks.held {
+		for _, h := range state.holders {
+			result = append(result, h.export(state.name))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Since.Before(result[j].Since) })
+	return result
+}
+
+// DebugMutex wraps a sync.Mutex and records the holding goroutine
+// together with its acquisition stack.
+// Locks held or waited for longer than DebugLockHoldThreshold
+// or DebugLockWaitThreshold are reported via DebugLockReportFunc.
+// If Name is set, then lock order inversions with other named
+// DebugMutex or DebugRWMutex locks are reported too.
+type DebugMutex struct {
+	Name  string
+	m     sync.Mutex
+	state debugLockState
+}
+
+func (d *DebugMutex) Lock() {
+	d.state.lock(d.Name, true, d.m.TryLock, d.m.Lock)
+}
+
+func (d *DebugMutex) Unlock() {
+	d.state.unlock(true)
+	d.m.Unlock()
+}
+
+// Stats returns the contention statistics of the mutex.
+func (d *DebugMutex) Stats() DebugLockStats {
+	return d.state.getStats()
+}
+
+// DebugRWMutex wraps a sync.RWMutex and records the holding goroutines
+// together with their acquisition stacks.
+// See DebugMutex for the reported problems.
+type DebugRWMutex struct {
+	Name  string
+	m     sync.RWMutex
+	state debugLockState
+}
+
+func (d *DebugRWMutex) RLock() {
+	d.state.lock(d.Name, false, d.m.TryRLock, d.m.RLock)
+}
+
+func (d *DebugRWMutex) RUnlock() {
+	d.state.unlock(false)
+	d.m.RUnlock()
+}
+
+func (d *DebugRWMutex) Lock() {
+	d.state.lock(d.Name, true, d.m.TryLock, d.m.Lock)
+}
+
+func (d *DebugRWMutex) Unlock() {
+	d.state.unlock(true)
+	d.m.Unlock()
+}
+
+func (d *DebugRWMutex) RLocker() sync.Locker {
+	return (*debugRLocker)(d)
+}
+
+// Stats returns the contention statistics of the mutex.
+func (d *DebugRWMutex) Stats() DebugLockStats {
+	return d.state.getStats()
+}
+
+type debugRLocker DebugRWMutex
+
+func (r *debugRLocker) Lock()   { (*DebugRWMutex)(r).RLock() }
+func (r *debugRLocker) Unlock() { (*DebugRWMutex)(r).RUnlock() }
+
+// debugLocks holds the global bookkeeping of all debug locks
+var debugLocks = struct {
+	mutex sync.Mutex
+	// held contains all locks with at least one holder
+	held map[*debugLockState]struct{}
+	// goroutines maps goroutine IDs to the named locks they hold
+	goroutines map[uint64][]*debugLockState
+	// order maps a lock name to the names of locks that
+	// have been acquired while holding it, with the first stack
+	order map[string]map[string]string
+}{
+	held:       make(map[*debugLockState]struct{}),
+	goroutines: make(map[uint64][]*debugLockState),
+	order:      make(map[string]map[string]string),
+}
+
+type debugLockHolder struct {
+	goroutine uint64
+	exclusive bool
+	since     time.Time
+	stack     []uintptr
+	timer     *time.Timer
+}
+
+func (h *debugLockHolder) export(name string) DebugLockHolder {
+	return DebugLockHolder{
+		Name:      name,
+		Goroutine: h.goroutine,
+		Exclusive: h.exclusive,
+		Since:     h.since,
+		Stack:     formatStack(h.stack),
+	}
+}
+
+// debugLockState is protected by debugLocks.mutex
+type debugLockState struct {
+	name    string
+	holders []*debugLockHolder
+	stats   DebugLockStats
+}
+
+func (s *debugLockState) lock(name string, exclusive bool, tryLock func() bool, lock func()) {
+	goroutine := goroutineID()
+	stack := callerStack(2)
+
+	if name != "" {
+		debugCheckLockOrder(name, goroutine, stack)
+	}
+
+	start := time.Now()
+	contended := !tryLock()
+	if contended {
+		var waitTimer *time.Timer
+		if DebugLockWaitThreshold > 0 {
+			waitTimer = time.AfterFunc(DebugLockWaitThreshold, func() {
+				debugLocks.mutex.Lock()
+				report := &DebugLockReport{
+					Kind:      DebugLockWaitedTooLong,
+					Name:      name,
+					Goroutine: goroutine,
+					Duration:  time.Since(start),
+					Stack:     formatStack(stack),
+				}
+				for _, h := range s.holders {
+					report.Holders = append(report.Holders, h.export(name))
+				}
+				debugLocks.mutex.Unlock()
+				debugLockReport(report)
+			})
+		}
+		lock()
+		if waitTimer != nil {
+			waitTimer.Stop()
+		}
+	}
+	acquired := time.Now()
+
+	holder := &debugLockHolder{
+		goroutine: goroutine,
+		exclusive: exclusive,
+		since:     acquired,
+		stack:     stack,
+	}
+	if DebugLockHoldThreshold > 0 {
+		holder.timer = time.AfterFunc(DebugLockHoldThreshold, func() {
+			debugLockReport(&DebugLockReport{
+				Kind:      DebugLockHeldTooLong,
+				Name:      name,
+				Goroutine: goroutine,
+				Duration:  time.Since(acquired),
+				Stack:     formatStack(stack),
+			})
+		})
+	}
+
+	debugLocks.mutex.Lock()
+	defer debugLocks.mutex.Unlock()
+
+	s.name = name
+	s.holders = append(s.holders, holder)
+	debugLocks.held[s] = struct{}{}
+	if name != "" {
+		debugLocks.goroutines[goroutine] = append(debugLocks.goroutines[goroutine], s)
+	}
+
+	s.stats.Acquisitions++
+	if contended {
+		wait := acquired.Sub(start)
+		s.stats.Contended++
+		s.stats.TotalWait += wait
+		s.stats.MaxWait = max(s.stats.MaxWait, wait)
+	}
+}
+
+func (s *debugLockState) unlock(exclusive bool) {
+	goroutine := goroutineID()
+	now := time.Now()
+
+	debugLocks.mutex.Lock()
+	defer debugLocks.mutex.Unlock()
+
+	// Prefer the holder of the calling goroutine,
+	// but a sync.Mutex may be unlocked by any goroutine
+	index := -1
+	for i, h := range s.holders {
+		if h.exclusive == exclusive {
+			if h.goroutine == goroutine {
+				index = i
+				break
+			}
+			if index == -1 {
+				index = i
+			}
+		}
+	}
+	if index == -1 {
+		return
+	}
+	holder := s.holders[index]
+	s.holders = append(s.holders[:index], s.holders[index+1:]...)
+	if len(s.holders) == 0 {
+		delete(debugLocks.held, s)
+	}
+	if holder.timer != nil {
+		holder.timer.Stop()
+	}
+
+	if locks := debugLocks.goroutines[holder.goroutine]; len(locks) > 0 {
+		for i := len(locks) - 1; i >= 0; i-- {
+			if locks[i] == s {
+				locks = append(locks[:i], locks[i+1:]...)
+				break
+			}
+		}
+		if len(locks) == 0 {
+			delete(debugLocks.goroutines, holder.goroutine)
+		} else {
+			debugLocks.goroutines[holder.goroutine] = locks
+		}
+	}
+
+	hold := now.Sub(holder.since)
+	s.stats.TotalHold += hold
+	s.stats.MaxHold = max(s.stats.MaxHold, hold)
+}
+
+func (s *debugLockState) getStats() DebugLockStats {
+	debugLocks.mutex.Lock()
+	defer debugLocks.mutex.Unlock()
+	return s.stats
+}
+
+// debugCheckLockOrder records that name is acquired after all named locks
+// currently held by goroutine and reports if the reverse order
+// has been observed before.
+func debugCheckLockOrder(name string, goroutine uint64, stack []uintptr) {
+	var reports []*DebugLockReport
+
+	debugLocks.mutex.Lock()
+	for _, held := range debugLocks.goroutines[goroutine] {
+		if held.name == name {
+			continue
+		}
+		after := debugLocks.order[held.name]
+		if _, known := after[name]; known {
+			continue
+		}
+		if reverseStack, found := debugLockOrderPath(name, held.name, make(map[string]bool)); found {
+			reports = append(reports, &DebugLockReport{
+				Kind:       DebugLockOrderInversion,
+				Name:       name,
+				Goroutine:  goroutine,
+				Stack:      formatStack(stack),
+				OtherName:  held.name,
+				OtherStack: reverseStack,
+			})
+		}
+		if after == nil {
+			after = make(map[string]string)
+			debugLocks.order[held.name] = after
+		}
+		after[name] = formatStack(stack)
+	}
+	debugLocks.mutex.Unlock()
+
+	for _, report := range reports {
+		debugLockReport(report)
+	}
+}
+
+// debugLockOrderPath returns if there is a recorded path of lock acquisitions
+// from the lock named from to the lock named to
+// together with the stack of the first edge of that path.
+func debugLockOrderPath(from, to string, visited map[string]bool) (stack string, found bool) {
+	visited[from] = true
+	for next, nextStack := range debugLocks.order[from] {
+		if next == to {
+			return nextStack, true
+		}
+		if !visited[next] {
+			if _, found := debugLockOrderPath(next, to, visited); found {
+				return nextStack, true
+			}
+		}
+	}
+	return "", false
+}
+
+func debugLockReport(report *DebugLockReport) {
+	if DebugLockReportFunc != nil {
+		DebugLockReportFunc(report)
+		return
+	}
+	slog.Warn(
+		"go-dry: "+report.Kind.String(),
+		"name", report.Name,
+		"goroutine", report.Goroutine,
+		"duration", report.Duration,
+		"report", report.String(),
+	)
+}
+
+// goroutineID returns the ID of the calling goroutine
+// parsed from the first line of its stack trace.
+func goroutineID() uint64 {
+	var buf [64]byte
+	data := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(data, ' '); i > 0 {
+		id, _ := strconv.ParseUint(string(data[:i]), 10, 64)
+		return id
+	}
+	return 0
+}
+
+// callerStack returns the program counters of the calling stack
+// skipping skipFrames frames above the caller of callerStack.
+func callerStack(skipFrames int) []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(skipFrames+2, pcs)]
+}
+
+// formatStack formats program counters as
+// function name and file:line pairs, one frame per line.
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package dry
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDebugMutexReports(t *testing.T) {
+	var (
+		reportsMutex sync.Mutex
+		reports      []*DebugLockReport
+	)
+	DebugLockReportFunc = func(report *DebugLockReport) {
+		reportsMutex.Lock()
+		reports = append(reports, report)
+		reportsMutex.Unlock()
+	}
+	DebugLockHoldThreshold = 10 * time.Millisecond
+	defer func() {
+		DebugLockReportFunc = nil
+		DebugLockHoldThreshold = 5 * time.Second
+	}()
+
+	a := &DebugMutex{Name: "TestDebugMutexReports.a"}
+	b := &DebugRWMutex{Name: "TestDebugMutexReports.b"}
+	// The lock order is recorded globally per name,
+	// forget it so that the test can run repeatedly
+	forgetOrder := func() {
+		debugLocks.mutex.Lock()
+		delete(debugLocks.order, a.Name)
+		delete(debugLocks.order, b.Name)
+		debugLocks.mutex.Unlock()
+	}
+	forgetOrder()
+	defer forgetOrder()
+
+	a.Lock()
+	b.Lock()
+	if holders := DebugLockHolders(); len(holders) < 2 {
+		t.Fatalf("expected at least 2 lock holders, got %d", len(holders))
+	}
+	b.Unlock()
+	a.Unlock()
+
+	b.RLock()
+	a.Lock()
+	time.Sleep(50 * time.Millisecond)
+	a.Unlock()
+	b.RUnlock()
+
+	reportsMutex.Lock()
+	defer reportsMutex.Unlock()
+	var inversion, heldTooLong bool
+	for _, report := range reports {
+		switch report.Kind {
+		case DebugLockOrderInversion:
+			inversion = report.Name == a.Name && report.OtherName == b.Name
+		case DebugLockHeldTooLong:
+			heldTooLong = true
+			if !strings.Contains(report.Stack, "TestDebugMutexReports") {
+				t.Errorf("stack does not contain calling function:\n%s", report.Stack)
+			}
+		}
+	}
+	if !inversion {
+		t.Error("lock order inversion not reported")
+	}
+	if !heldTooLong {
+		t.Error("lock held too long not reported")
+	}
+
+	if stats := a.Stats(); stats.Acquisitions != 2 || stats.MaxHold < 50*time.Millisecond {
+		t.Errorf("invalid stats: %#v", stats)
+	}
+}
@@ -0,0 +1,346 @@
+package dry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// DirDiffCompare selects how DirDiff detects changed files.
+type DirDiffCompare int
+
+const (
+	// DirDiffSizeModTime compares the size and modification time of files.
+	DirDiffSizeModTime DirDiffCompare = iota
+	// DirDiffCRC64 compares the size and FileCRC64 checksum of files.
+	DirDiffCRC64
+	// DirDiffMD5 compares the size and FileMD5Bytes hash of files.
+	DirDiffMD5
+)
+
+// DirDiffOptions configures DirDiff and DirSync.
+// A nil *DirDiffOptions is valid and uses the defaults.
+type DirDiffOptions struct {
+	// Compare defaults to DirDiffSizeModTime
+	Compare DirDiffCompare
+	// Include limits the compared files to the ones matching at least
+	// one pattern, see WalkDirOptions for the pattern syntax
+	Include []string
+	// Exclude skips matching files and directories
+	Exclude []string
+}
+
+// DirDiffResult holds the '/' separated paths relative to the compared
+// directories in lexical order.
+type DirDiffResult struct {
+	// Added exist only in the second directory
+	Added []string
+	// Removed exist only in the first directory
+	Removed []string
+	// Changed exist in both directories with different content
+	// or a different type like file and directory
+	Changed []string
+}
+
+// Equal returns if there are no differences.
+func (d *DirDiffResult) Equal() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+/*
+DirDiff compares the directory trees a and b and returns which files
+and directories were added, removed or changed going from a to b.
+A non existing directory is handled like an empty one.
+Symbolic links are not followed, their targets are compared.
+Errors of unreadable files are returned as ErrorList
+together with the differences that could be found.
+*/
+func DirDiff(a, b string, opts *DirDiffOptions) (*DirDiffResult, error) {
+	diff, _, _, err := dirDiff(a, b, opts)
+	return diff, err
+}
+
+func dirDiff(a, b string, opts *DirDiffOptions) (diff *DirDiffResult, aInfos, bInfos map[string]fs.FileInfo, err error) {
+	if opts == nil {
+		opts = &DirDiffOptions{}
+	}
+	var errs ErrorList
+	aInfos = dirDiffInfos(a, opts, &errs)
+	bInfos = dirDiffInfos(b, opts, &errs)
+
+	diff = &DirDiffResult{}
+	for relPath, aInfo := range aInfos {
+		bInfo, ok := bInfos[relPath]
+		if !ok {
+			diff.Removed = append(diff.Removed, relPath)
+			continue
+		}
+		changed, err := dirDiffChanged(a, b, relPath, aInfo, bInfo, opts.Compare)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if changed {
+			diff.Changed = append(diff.Changed, relPath)
+		}
+	}
+	for relPath := range bInfos {
+		if _, ok := aInfos[relPath]; !ok {
+			diff.Added = append(diff.Added, relPath)
+		}
+	}
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.Sort(diff.Changed)
+	return diff, aInfos, bInfos, errs.Err()
+}
+
+func dirDiffInfos(dir string, opts *DirDiffOptions, errs *ErrorList) map[string]fs.FileInfo {
+	infos := make(map[string]fs.FileInfo)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return infos
+	}
+	walkOpts := &WalkDirOptions{Exclude: opts.Exclude, Dirs: true}
+	var dirs []string
+	for entry, err := range WalkDir(dir, walkOpts) {
+		if err != nil {
+			*errs = append(*errs, err)
+			continue
+		}
+		if entry.Info.IsDir() {
+			dirs = append(dirs, entry.RelPath)
+		}
+		if len(opts.Include) == 0 || entry.Info.IsDir() || walkMatchAny(opts.Include, entry.RelPath) {
+			infos[entry.RelPath] = entry.Info
+		}
+	}
+	if len(opts.Include) > 0 {
+		// Remove directories without included files
+		for _, relDir := range dirs {
+			hasFiles := false
+			for relPath, info := range infos {
+				if !info.IsDir() && len(relPath) > len(relDir) && relPath[:len(relDir)+1] == relDir+"/" {
+					hasFiles = true
+					break
+				}
+			}
+			if !hasFiles {
+				delete(infos, relDir)
+			}
+		}
+	}
+	return infos
+}
+
+func dirDiffChanged(a, b, relPath string, aInfo, bInfo fs.FileInfo, compare DirDiffCompare) (bool, error) {
+	if aInfo.Mode().Type() != bInfo.Mode().Type() {
+		return true, nil
+	}
+	aPath := filepath.Join(a, filepath.FromSlash(relPath))
+	bPath := filepath.Join(b, filepath.FromSlash(relPath))
+	switch {
+	case aInfo.IsDir():
+		return false, nil
+	case aInfo.Mode()&fs.ModeSymlink != 0:
+		aTarget, err := os.Readlink(aPath)
+		if err != nil {
+			return false, err
+		}
+		bTarget, err := os.Readlink(bPath)
+		if err != nil {
+			return false, err
+		}
+		return aTarget != bTarget, nil
+	case aInfo.Size() != bInfo.Size():
+		return true, nil
+	}
+	switch compare {
+	case DirDiffCRC64:
+		aCRC, err := FileCRC64(aPath)
+		if err != nil {
+			return false, err
+		}
+		bCRC, err := FileCRC64(bPath)
+		if err != nil {
+			return false, err
+		}
+		return aCRC != bCRC, nil
+	case DirDiffMD5:
+		aHash, err := FileMD5Bytes(aPath)
+		if err != nil {
+			return false, err
+		}
+		bHash, err := FileMD5Bytes(bPath)
+		if err != nil {
+			return false, err
+		}
+		return !bytes.Equal(aHash, bHash), nil
+	default:
+		return !aInfo.ModTime().Equal(bInfo.ModTime()), nil
+	}
+}
+
+// DirSyncOp is the operation of a DirSyncAction.
+type DirSyncOp string
+
+const (
+	DirSyncMkdir  DirSyncOp = "mkdir"
+	DirSyncCopy   DirSyncOp = "copy"
+	DirSyncDelete DirSyncOp = "delete"
+)
+
+// DirSyncAction is an operation planned or executed by DirSync.
+type DirSyncAction struct {
+	Op DirSyncOp
+	// RelPath is the '/' separated path relative to the directories
+	RelPath string
+}
+
+func (a DirSyncAction) String() string {
+	return fmt.Sprintf("%s %s", a.Op, a.RelPath)
+}
+
+// DirSyncOptions configures DirSync.
+// A nil *DirSyncOptions is valid and uses the defaults.
+type DirSyncOptions struct {
+	DirDiffOptions
+	// Delete removes files and directories from the destination
+	// that don't exist in the source. Only files matching
+	// Include and Exclude are deleted, and directories
+	// only if they don't contain other files.
+	Delete bool
+	// DryRun only returns the planned actions
+	// without changing the destination
+	DryRun bool
+}
+
+/*
+DirSync makes the directory dest a copy of the directory source
+by copying only added and changed files, and deleting files
+missing in source if opts.Delete is true.
+Modification times and permissions of copied files are preserved
+so that DirDiffSizeModTime detects them as unchanged in the next run.
+Symbolic links are re-created.
+
+The returned actions are in the order they are, or would be
+with opts.DryRun, executed.
+Failures of individual actions don't stop the synchronization
+and are returned together as ErrorList.
+
+Usage example:
+
+	actions, err := dry.DirSync("build", "/var/www", &dry.DirSyncOptions{
+		Delete: true,
+		DryRun: true,
+	})
+	for _, action := range actions {
+		fmt.Println(action)
+	}
+*/
+func DirSync(source, dest string, opts *DirSyncOptions) ([]DirSyncAction, error) {
+	if opts == nil {
+		opts = &DirSyncOptions{}
+	}
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if !sourceInfo.IsDir() {
+		return nil, &FileCopyError{"Source is not a directory"}
+	}
+	var errs ErrorList
+	diff, destInfos, sourceInfos, err := dirDiff(dest, source, &opts.DirDiffOptions)
+	if err != nil {
+		errs = append(errs, AsErrorList(err)...)
+	}
+
+	var actions []DirSyncAction
+	if !FileIsDir(dest) {
+		actions = append(actions, DirSyncAction{Op: DirSyncMkdir})
+	}
+	// Changed and added paths sorted together so that
+	// directories are created before their content
+	changed := append(slices.Clone(diff.Added), diff.Changed...)
+	slices.Sort(changed)
+	for _, relPath := range changed {
+		if _, exists := destInfos[relPath]; exists && sourceInfos[relPath].IsDir() != destInfos[relPath].IsDir() {
+			actions = append(actions, DirSyncAction{Op: DirSyncDelete, RelPath: relPath})
+		}
+		if sourceInfos[relPath].IsDir() {
+			actions = append(actions, DirSyncAction{Op: DirSyncMkdir, RelPath: relPath})
+		} else {
+			actions = append(actions, DirSyncAction{Op: DirSyncCopy, RelPath: relPath})
+		}
+	}
+	if opts.Delete {
+		// Deepest first
+		for i := len(diff.Removed) - 1; i >= 0; i-- {
+			relPath := diff.Removed[i]
+			if destInfos[relPath].IsDir() && dirSyncHasUnlisted(dest, relPath, destInfos) {
+				// Keep directories with files excluded by the filters
+				continue
+			}
+			actions = append(actions, DirSyncAction{Op: DirSyncDelete, RelPath: relPath})
+		}
+	}
+	if opts.DryRun {
+		return actions, errs.Err()
+	}
+
+	copier := &dirCopier{
+		opts:   &CopyDirOptions{Existing: CopyDirOverwrite, PreserveTimes: true},
+		source: source,
+		dest:   dest,
+	}
+	for _, action := range actions {
+		destPath := filepath.Join(dest, filepath.FromSlash(action.RelPath))
+		switch action.Op {
+		case DirSyncMkdir:
+			info, ok := sourceInfos[action.RelPath]
+			if !ok {
+				info = sourceInfo
+			}
+			err = os.MkdirAll(destPath, info.Mode().Perm())
+		case DirSyncCopy:
+			copier.copy(&copyDirFile{relPath: action.RelPath, destPath: destPath, info: sourceInfos[action.RelPath]})
+			err = nil
+		case DirSyncDelete:
+			if _, inSource := sourceInfos[action.RelPath]; inSource {
+				// Replaced by a different type from source
+				err = os.RemoveAll(destPath)
+			} else {
+				// Files are deleted before their directories,
+				// so removed directories are empty
+				err = os.Remove(destPath)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, copier.errs...)
+	return actions, errs.Err()
+}
+
+// dirSyncHasUnlisted returns if the directory relDir in dest
+// contains a file or directory that is not in infos
+func dirSyncHasUnlisted(dest, relDir string, infos map[string]fs.FileInfo) bool {
+	entries, err := os.ReadDir(filepath.Join(dest, filepath.FromSlash(relDir)))
+	if err != nil {
+		return true
+	}
+	for _, entry := range entries {
+		relPath := relDir + "/" + entry.Name()
+		if _, ok := infos[relPath]; !ok {
+			return true
+		}
+		if entry.IsDir() && dirSyncHasUnlisted(dest, relPath, infos) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package dry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDirDiff(t *testing.T) {
+	a := t.TempDir()
+	b := t.TempDir()
+	os.Mkdir(filepath.Join(a, "removed"), 0o755)
+	FileSetString(filepath.Join(a, "removed", "file.txt"), "")
+	FileSetString(filepath.Join(a, "same.txt"), "same")
+	FileSetString(filepath.Join(a, "changed.txt"), "aaa")
+	os.Mkdir(filepath.Join(b, "added"), 0o755)
+	FileSetString(filepath.Join(b, "same.txt"), "same")
+	FileSetString(filepath.Join(b, "changed.txt"), "bbb")
+	modified := time.Now().Add(-time.Hour)
+	for _, filename := range []string{"same.txt", "changed.txt"} {
+		os.Chtimes(filepath.Join(a, filename), modified, modified)
+		os.Chtimes(filepath.Join(b, filename), modified, modified)
+	}
+
+	diff, err := DirDiff(a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same size and modification time
+	expected := &DirDiffResult{Added: []string{"added"}, Removed: []string{"removed", "removed/file.txt"}}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("got %+v, expected %+v", diff, expected)
+	}
+
+	for _, compare := range []DirDiffCompare{DirDiffCRC64, DirDiffMD5} {
+		diff, err = DirDiff(a, b, &DirDiffOptions{Compare: compare, Exclude: []string{"removed"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = &DirDiffResult{Added: []string{"added"}, Changed: []string{"changed.txt"}}
+		if !reflect.DeepEqual(diff, expected) {
+			t.Errorf("compare %d: got %+v, expected %+v", compare, diff, expected)
+		}
+	}
+
+	diff, err = DirDiff(a, a, nil)
+	if err != nil || !diff.Equal() {
+		t.Errorf("directory differs from itself: %+v, %v", diff, err)
+	}
+}
+
+func TestDirSync(t *testing.T) {
+	source := walkDirTestTree(t)
+	dest := filepath.Join(t.TempDir(), "dest")
+
+	actions, err := DirSync(source, dest, &DirSyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) == 0 || actions[0] != (DirSyncAction{Op: DirSyncMkdir}) {
+		t.Errorf("invalid actions: %v", actions)
+	}
+	if FileExists(dest) {
+		t.Fatal("dry run created destination")
+	}
+
+	_, err = DirSync(source, dest, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := DirDiff(source, dest, nil)
+	if err != nil || !diff.Equal() {
+		t.Fatalf("destination differs after sync: %+v, %v", diff, err)
+	}
+
+	FileSetString(filepath.Join(source, "main.go"), "package main")
+	os.RemoveAll(filepath.Join(source, "vendor"))
+	actions, err = DirSync(source, dest, &DirSyncOptions{Delete: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DirSyncAction{
+		{Op: DirSyncCopy, RelPath: "main.go"},
+		{Op: DirSyncDelete, RelPath: "vendor/lib/lib.go"},
+		{Op: DirSyncDelete, RelPath: "vendor/lib"},
+		{Op: DirSyncDelete, RelPath: "vendor"},
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("got %v, expected %v", actions, expected)
+	}
+
+	_, err = DirSync(source, dest, &DirSyncOptions{Delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err = DirDiff(source, dest, nil)
+	if err != nil || !diff.Equal() {
+		t.Errorf("destination differs after sync: %+v, %v", diff, err)
+	}
+}
+
+func TestDirSyncDeleteFiltered(t *testing.T) {
+	source := t.TempDir()
+	dest := t.TempDir()
+	os.Mkdir(filepath.Join(dest, "pkg"), 0755)
+	FileSetString(filepath.Join(dest, "pkg", "a.go"), "package pkg")
+	FileSetString(filepath.Join(dest, "pkg", "data.bin"), "data")
+
+	opts := &DirSyncOptions{Delete: true}
+	opts.Include = []string{"*.go"}
+	actions, err := DirSync(source, dest, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DirSyncAction{{Op: DirSyncDelete, RelPath: "pkg/a.go"}}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("got %v, expected %v", actions, expected)
+	}
+	if FileExists(filepath.Join(dest, "pkg", "a.go")) {
+		t.Error("included file not deleted")
+	}
+	if !FileExists(filepath.Join(dest, "pkg", "data.bin")) {
+		t.Error("file not matching Include deleted")
+	}
+}
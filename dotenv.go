@@ -0,0 +1,428 @@
+package dry
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+FileGetDotenv reads a .env file and returns its variables as map.
+In addition to the KEY=value lines of FileGetConfig, it supports:
+
+	# comments on their own line or after unquoted values
+	export KEY=value
+	KEY='literal value without escapes or expansion'
+	KEY="value with \n escapes and ${EXPANSION}"
+	KEY="values in quotes
+	can span multiple lines"
+	KEY=${OTHER:-default}/path
+
+Variables are expanded in unquoted and double quoted values
+as $NAME, ${NAME}, ${NAME:-default} (default if unset or empty)
+and ${NAME-default} (default if unset).
+Variables defined earlier in the file take precedence over the environment.
+A dollar sign can be escaped as \$.
+Syntax errors are returned as *FileParseError.
+*/
+func FileGetDotenv(filenameOrURL string, timeout ...time.Duration) (map[string]string, error) {
+	data, err := FileGetBytes(filenameOrURL, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseDotenv(data, os.LookupEnv, false)
+	if err != nil {
+		return nil, fileParseErrorWithFilename(err, filenameOrURL)
+	}
+	env := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		env[entry.Key] = entry.Value
+	}
+	return env, nil
+}
+
+// LoadDotenv reads the passed dotenv files, or ".env" if none is passed,
+// and sets their variables in the environment of the process.
+// If override is false, then variables that are already set in the environment,
+// including those set by an earlier file, are not changed and
+// their environment values are used for expansions within the files.
+// If override is true, then the files replace existing variables
+// and later files take precedence over earlier ones.
+// See FileGetDotenv for the supported syntax.
+func LoadDotenv(override bool, filenames ...string) error {
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+	for _, filename := range filenames {
+		data, err := FileGetBytes(filename)
+		if err != nil {
+			return err
+		}
+		entries, err := parseDotenv(data, os.LookupEnv, !override)
+		if err != nil {
+			return fileParseErrorWithFilename(err, filename)
+		}
+		for _, entry := range entries {
+			if !override {
+				if _, exists := os.LookupEnv(entry.Key); exists {
+					continue
+				}
+			}
+			err = os.Setenv(entry.Key, entry.Value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FileSetDotenv writes env as dotenv file.
+// If the file already exists, then its comments, blank lines, line order
+// and the formatting of unchanged values are preserved.
+// Changed values are replaced in place, variables missing in env
+// are removed and new variables are appended sorted by name.
+// Lines with expansions that would result in a different value
+// because a referenced variable changed are written with the literal value.
+func FileSetDotenv(filename string, env map[string]string) error {
+	var (
+		lines   []string
+		entries []dotenvEntry
+	)
+	data, err := os.ReadFile(filename) //#nosec G304
+	switch {
+	case err == nil:
+		entries, err = parseDotenv(data, os.LookupEnv, false)
+		if err != nil {
+			return fileParseErrorWithFilename(err, filename)
+		}
+		lines = strings.Split(strings.TrimSuffix(dotenvNormalizeNewlines(data), "\n"), "\n")
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	for key := range env {
+		if !dotenvValidKey(key) {
+			return fmt.Errorf("invalid dotenv variable name '%s'", key)
+		}
+	}
+
+	// Parse the result and write the values of keys that
+	// are read back differently literally until all match env
+	literal := make(map[string]bool)
+	for {
+		data = dotenvFormat(lines, entries, env, literal)
+		parsed, err := parseDotenv(data, os.LookupEnv, false)
+		if err != nil {
+			return err
+		}
+		changed := false
+		for _, entry := range parsed {
+			if entry.Value != env[entry.Key] && !literal[entry.Key] {
+				literal[entry.Key] = true
+				changed = true
+			}
+		}
+		if !changed {
+			return FileSetBytes(filename, data)
+		}
+	}
+}
+
+// dotenvFormat returns the dotenv file for env using the lines and
+// parsed entries of an existing file, keys in literal are not
+// written as they are in lines but with their literal value
+func dotenvFormat(lines []string, entries []dotenvEntry, env map[string]string, literal map[string]bool) []byte {
+	var (
+		buf     bytes.Buffer
+		written = make(map[string]bool, len(env))
+		next    = 0 // next line index to copy
+	)
+	for _, entry := range entries {
+		for ; next < entry.FirstLine; next++ {
+			buf.WriteString(lines[next])
+			buf.WriteByte('\n')
+		}
+		next = entry.LastLine + 1
+		value, keep := env[entry.Key]
+		if !keep || written[entry.Key] {
+			continue
+		}
+		written[entry.Key] = true
+		if value == entry.Value && !literal[entry.Key] {
+			for i := entry.FirstLine; i <= entry.LastLine; i++ {
+				buf.WriteString(lines[i])
+				buf.WriteByte('\n')
+			}
+			continue
+		}
+		if entry.Export {
+			buf.WriteString("export ")
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", entry.Key, dotenvFormatValue(value))
+	}
+	for ; next < len(lines); next++ {
+		buf.WriteString(lines[next])
+		buf.WriteByte('\n')
+	}
+
+	newKeys := make([]string, 0, len(env))
+	for key := range env {
+		if !written[key] {
+			newKeys = append(newKeys, key)
+		}
+	}
+	sort.Strings(newKeys)
+	for _, key := range newKeys {
+		fmt.Fprintf(&buf, "%s=%s\n", key, dotenvFormatValue(env[key]))
+	}
+	return buf.Bytes()
+}
+
+type dotenvEntry struct {
+	Key    string
+	Value  string
+	Export bool
+	// FirstLine and LastLine are zero based line indices,
+	// they differ for multi-line values
+	FirstLine int
+	LastLine  int
+}
+
+// parseDotenv parses dotenv data. Variables for expansion are looked up
+// in the previously parsed entries and via lookupEnv,
+// if preferEnv is true lookupEnv is tried first.
+func parseDotenv(data []byte, lookupEnv func(string) (string, bool), preferEnv bool) (entries []dotenvEntry, err error) {
+	lines := strings.Split(dotenvNormalizeNewlines(data), "\n")
+	vars := make(map[string]string)
+	lookup := func(name string) (string, bool) {
+		if preferEnv {
+			if value, ok := lookupEnv(name); ok {
+				return value, true
+			}
+		}
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		if !preferEnv {
+			return lookupEnv(name)
+		}
+		return "", false
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		entry := dotenvEntry{FirstLine: i, LastLine: i}
+		if rest, ok := strings.CutPrefix(line, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			entry.Export = true
+			line = strings.TrimLeft(rest, " \t")
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, &FileParseError{Line: i + 1, Message: "missing '=' after variable name"}
+		}
+		entry.Key = strings.TrimSpace(key)
+		if !dotenvValidKey(entry.Key) {
+			return nil, &FileParseError{Line: i + 1, Message: fmt.Sprintf("invalid variable name '%s'", entry.Key)}
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if value != "" && (value[0] == '\'' || value[0] == '"') {
+			quote := value[0]
+			// Append following lines until the closing quote is found
+			end := dotenvClosingQuote(value, quote)
+			for end == -1 {
+				if entry.LastLine+1 >= len(lines) {
+					return nil, &FileParseError{Line: i + 1, Message: fmt.Sprintf("unterminated %c quoted value", quote)}
+				}
+				entry.LastLine++
+				value += "\n" + lines[entry.LastLine]
+				end = dotenvClosingQuote(value, quote)
+			}
+			if rest := strings.TrimSpace(value[end+1:]); rest != "" && rest[0] != '#' {
+				return nil, &FileParseError{Line: entry.LastLine + 1, Message: fmt.Sprintf("unexpected characters after quoted value: %s", rest)}
+			}
+			value = value[1:end]
+			if quote == '"' {
+				value, err = dotenvExpand(value, true, lookup)
+			}
+		} else {
+			if pos := strings.Index(value, " #"); pos != -1 {
+				value = value[:pos]
+			}
+			if pos := strings.Index(value, "\t#"); pos != -1 {
+				value = value[:pos]
+			}
+			value, err = dotenvExpand(strings.TrimSpace(value), false, lookup)
+		}
+		if err != nil {
+			return nil, &FileParseError{Line: i + 1, Message: err.Error()}
+		}
+
+		entry.Value = value
+		vars[entry.Key] = value
+		entries = append(entries, entry)
+		i = entry.LastLine
+	}
+	return entries, nil
+}
+
+// dotenvClosingQuote returns the index of the closing quote in s
+// which starts with the opening quote, or -1.
+func dotenvClosingQuote(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// dotenvExpand expands variables in s and
+// resolves backslash escape sequences if unescape is true.
+// An escaped dollar sign \$ is never expanded.
+func dotenvExpand(s string, unescape bool, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteByte('$')
+			i++
+
+		case c == '\\' && unescape && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+
+		case c == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := dotenvClosingBrace(s[i:])
+			if end == -1 {
+				return "", fmt.Errorf("unterminated variable expansion: %s", s[i:])
+			}
+			expr := s[i+2 : i+end]
+			i += end
+
+			name, defaultValue, hasDefault, defaultIfEmpty := expr, "", false, false
+			if pos := strings.IndexAny(expr, ":-"); pos != -1 {
+				name = expr[:pos]
+				switch {
+				case strings.HasPrefix(expr[pos:], ":-"):
+					defaultValue, hasDefault, defaultIfEmpty = expr[pos+2:], true, true
+				case expr[pos] == '-':
+					defaultValue, hasDefault = expr[pos+1:], true
+				default:
+					return "", fmt.Errorf("invalid variable expansion: ${%s}", expr)
+				}
+			}
+			if !dotenvValidKey(name) {
+				return "", fmt.Errorf("invalid variable expansion: ${%s}", expr)
+			}
+			value, ok := lookup(name)
+			if hasDefault && (!ok || (defaultIfEmpty && value == "")) {
+				var err error
+				value, err = dotenvExpand(defaultValue, false, lookup)
+				if err != nil {
+					return "", err
+				}
+			}
+			b.WriteString(value)
+
+		case c == '$' && i+1 < len(s) && dotenvIsNameChar(s[i+1]):
+			end := i + 1
+			for end < len(s) && dotenvIsNameChar(s[end]) {
+				end++
+			}
+			value, _ := lookup(s[i+1 : end])
+			b.WriteString(value)
+			i = end - 1
+
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// dotenvClosingBrace returns the index of the brace closing
+// the expansion at the start of s, or -1.
+func dotenvClosingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// dotenvFormatValue returns value unquoted if possible,
+// else double quoted with escaped special characters.
+func dotenvFormatValue(value string) string {
+	needsQuotes := false
+	for i := 0; i < len(value); i++ {
+		if !dotenvIsNameChar(value[i]) && strings.IndexByte("./:@,+-%", value[i]) == -1 {
+			needsQuotes = true
+			break
+		}
+	}
+	if !needsQuotes {
+		return value
+	}
+	return `"` + strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	).Replace(value) + `"`
+}
+
+func dotenvValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !dotenvIsNameChar(key[i]) && key[i] != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func dotenvIsNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func dotenvNormalizeNewlines(data []byte) string {
+	return strings.ReplaceAll(string(data), "\r\n", "\n")
+}
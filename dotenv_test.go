@@ -0,0 +1,137 @@
+package dry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const dotenvTestData = `# comment
+export HOST=localhost
+PORT = 8080 # inline comment
+URL="http://${HOST}:$PORT/\$x"
+LITERAL='${HOST}\n'
+MULTI="line 1
+line 2"
+DEFAULT=${GO_DRY_BOGUS_ENVIRONMENT_VARIABLE:-${HOST}}
+`
+
+func TestFileGetDotenv(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".env")
+	err := FileSetString(filename, dotenvTestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := FileGetDotenv(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"HOST":    "localhost",
+		"PORT":    "8080",
+		"URL":     "http://localhost:8080/$x",
+		"LITERAL": `${HOST}\n`,
+		"MULTI":   "line 1\nline 2",
+		"DEFAULT": "localhost",
+	}
+	if len(env) != len(expected) {
+		t.Fatalf("expected %d variables, got %#v", len(expected), env)
+	}
+	for key, value := range expected {
+		if env[key] != value {
+			t.Errorf("%s: expected %q, got %q", key, value, env[key])
+		}
+	}
+
+	err = FileSetString(filename, "A=1\nB='unterminated\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = FileGetDotenv(filename)
+	if parseErr, ok := err.(*FileParseError); !ok || parseErr.Line != 2 {
+		t.Fatalf("expected *FileParseError for line 2, got %#v", err)
+	}
+}
+
+func TestFileSetDotenv(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".env")
+	err := FileSetString(filename, dotenvTestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := FileGetDotenv(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env["PORT"] = "9090"
+	env["NEW"] = "with space"
+	delete(env, "MULTI")
+	err = FileSetDotenv(filename, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := FileGetString(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# comment
+export HOST=localhost
+PORT=9090
+URL="http://localhost:8080/\$x"
+LITERAL='${HOST}\n'
+DEFAULT=${GO_DRY_BOGUS_ENVIRONMENT_VARIABLE:-${HOST}}
+NEW="with space"
+`
+	if result != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+	reread, err := FileGetDotenv(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reread, env) {
+		t.Errorf("read %v after writing %v", reread, env)
+	}
+
+	// Changing HOST rewrites the values that expand it
+	env["HOST"] = "example.com"
+	err = FileSetDotenv(filename, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reread, err = FileGetDotenv(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reread, env) {
+		t.Errorf("read %v after writing %v", reread, env)
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".env")
+	err := FileSetString(filename, "GO_DRY_TEST_A=file\nGO_DRY_TEST_B=$GO_DRY_TEST_A\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GO_DRY_TEST_A", "env")
+	t.Setenv("GO_DRY_TEST_B", "")
+	os.Unsetenv("GO_DRY_TEST_B")
+
+	err = LoadDotenv(false, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("GO_DRY_TEST_A") != "env" || os.Getenv("GO_DRY_TEST_B") != "env" {
+		t.Fatalf("no override: A=%q B=%q", os.Getenv("GO_DRY_TEST_A"), os.Getenv("GO_DRY_TEST_B"))
+	}
+
+	err = LoadDotenv(true, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("GO_DRY_TEST_A") != "file" || os.Getenv("GO_DRY_TEST_B") != "file" {
+		t.Fatalf("override: A=%q B=%q", os.Getenv("GO_DRY_TEST_A"), os.Getenv("GO_DRY_TEST_B"))
+	}
+}
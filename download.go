@@ -0,0 +1,232 @@
+package dry
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// FileDownloadOptions configures FileDownload.
+// A nil *FileDownloadOptions is valid and uses the defaults.
+type FileDownloadOptions struct {
+	// Context of the HTTP requests, defaults to context.Background()
+	Context context.Context
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+	// Header is added to the HTTP requests
+	Header http.Header
+	// SHA256 is the expected hex encoded SHA-256 hash of the file
+	SHA256 string
+	// MD5 is the expected hex encoded MD5 hash of the file
+	MD5 string
+	// Progress is called while downloading
+	Progress func(FileDownloadProgress)
+}
+
+// FileDownloadProgress is passed to FileDownloadOptions.Progress.
+type FileDownloadProgress struct {
+	// Bytes of the file downloaded including resumed bytes
+	Bytes int64
+	// Total size of the file or -1 if unknown
+	Total int64
+	// Resumed bytes from a previous partial download
+	Resumed int64
+}
+
+// FileDownloadChecksumError is returned by FileDownload
+// if the downloaded file does not match an expected checksum.
+type FileDownloadChecksumError struct {
+	URL       string
+	Algorithm HashAlgorithm
+	Expected  string
+	Actual    string
+}
+
+func (e *FileDownloadChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch for %s: expected %s, got %s", e.Algorithm, e.URL, e.Expected, e.Actual)
+}
+
+/*
+FileDownload downloads url to the file dest.
+The data is streamed to the temporary file dest+".part"
+in the same directory, which is renamed to dest after the
+download is complete and all expected checksums match.
+On a checksum mismatch the temporary file is deleted.
+
+If a download fails, the temporary file is kept and a later
+call resumes it with a Range request if the server supports it.
+The ETag or Last-Modified header of the first response is stored
+in the file dest+".part.ifrange" and passed as If-Range header,
+so that a changed file on the server is downloaded completely again.
+
+Usage example:
+
+	err := dry.FileDownload("https://example.com/release.tar.gz", "release.tar.gz", &dry.FileDownloadOptions{
+		SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		Progress: func(p dry.FileDownloadProgress) {
+			fmt.Printf("\r%d of %d bytes", p.Bytes, p.Total)
+		},
+	})
+*/
+func FileDownload(url, dest string, opts *FileDownloadOptions) error {
+	if opts == nil {
+		opts = &FileDownloadOptions{}
+	}
+	partFile := dest + ".part"
+	ifRangeFile := partFile + ".ifrange"
+
+	// Resume only if the validator of the partial data is known
+	var offset int64
+	ifRange, _ := FileGetString(ifRangeFile)
+	if ifRange != "" {
+		offset = FileSize(partFile)
+	}
+	if offset <= 0 {
+		offset = 0
+		os.Remove(partFile)    //#nosec G104
+		os.Remove(ifRangeFile) //#nosec G104
+	}
+
+	response, err := fileDownloadRequest(url, opts, offset, ifRange)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0 {
+		// Partial file is invalid, start again
+		response.Body.Close() //#nosec G104
+		offset = 0
+		response, err = fileDownloadRequest(url, opts, 0, "")
+		if err != nil {
+			return err
+		}
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent && offset > 0:
+		if !strings.HasPrefix(response.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return fmt.Errorf("invalid Content-Range %q for resumed download of %s", response.Header.Get("Content-Range"), url)
+		}
+	case response.StatusCode >= 200 && response.StatusCode <= 299:
+		// Server sent the complete file
+		offset = 0
+		ifRange = response.Header.Get("ETag")
+		if ifRange == "" || strings.HasPrefix(ifRange, "W/") {
+			// Weak ETags can't be used for If-Range
+			ifRange = response.Header.Get("Last-Modified")
+		}
+		os.Remove(ifRangeFile) //#nosec G104
+		if ifRange != "" {
+			err = FileSetString(ifRangeFile, ifRange)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%d: %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flag = os.O_RDWR | os.O_APPEND
+	}
+	file, err := os.OpenFile(partFile, flag, 0644) //#nosec G304
+	if err != nil {
+		return err
+	}
+	defer file.Close() //#nosec G307
+
+	var (
+		hashes   = make(map[HashAlgorithm]hash.Hash)
+		writers  = []io.Writer{file}
+		expected = map[HashAlgorithm]string{HashSHA256: opts.SHA256, HashMD5: opts.MD5}
+	)
+	for algo, sum := range expected {
+		if sum != "" {
+			hashes[algo], _ = algo.New()
+			writers = append(writers, hashes[algo])
+		}
+	}
+	if offset > 0 && len(hashes) > 0 {
+		// Hash the resumed data
+		_, err = io.Copy(io.MultiWriter(writers[1:]...), io.NewSectionReader(file, 0, offset))
+		if err != nil {
+			return err
+		}
+	}
+
+	total := int64(-1)
+	if response.ContentLength >= 0 {
+		total = offset + response.ContentLength
+	}
+	counter := &CountingReader{Reader: response.Body}
+	reader := io.Reader(counter)
+	if opts.Progress != nil {
+		opts.Progress(FileDownloadProgress{Bytes: offset, Total: total, Resumed: offset})
+		reader = ReaderFunc(func(p []byte) (int, error) {
+			n, err := counter.Read(p)
+			if n > 0 {
+				opts.Progress(FileDownloadProgress{Bytes: offset + int64(counter.BytesRead), Total: total, Resumed: offset})
+			}
+			return n, err
+		})
+	}
+	_, err = io.Copy(io.MultiWriter(writers...), reader)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if FileExists(ifRangeFile) {
+			return fmt.Errorf("download of %s interrupted, can be resumed: %w", url, err)
+		}
+		os.Remove(partFile) //#nosec G104
+		return err
+	}
+
+	for algo, h := range hashes {
+		actual := hex.EncodeToString(h.Sum(nil))
+		if !strings.EqualFold(actual, expected[algo]) {
+			os.Remove(partFile)    //#nosec G104
+			os.Remove(ifRangeFile) //#nosec G104
+			return &FileDownloadChecksumError{URL: url, Algorithm: algo, Expected: expected[algo], Actual: actual}
+		}
+	}
+
+	err = os.Rename(partFile, dest)
+	if err != nil {
+		return err
+	}
+	os.Remove(ifRangeFile) //#nosec G104
+	return nil
+}
+
+func fileDownloadRequest(url string, opts *FileDownloadOptions, offset int64, ifRange string) (*http.Response, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range opts.Header {
+		request.Header[key] = values
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		request.Header.Set("If-Range", ifRange)
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(request)
+}
@@ -0,0 +1,85 @@
+package dry
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileDownload(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 10000))
+	etag := `"v1"`
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "data.txt", time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+	dest := filepath.Join(t.TempDir(), "data.txt")
+	sha256 := StringHash(string(data), HashSHA256)
+
+	var last FileDownloadProgress
+	err := FileDownload(server.URL, dest, &FileDownloadOptions{
+		SHA256:   sha256,
+		MD5:      StringHash(string(data), HashMD5),
+		Progress: func(p FileDownloadProgress) { last = p },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := FileGetBytes(dest); !bytes.Equal(result, data) {
+		t.Error("invalid downloaded data")
+	}
+	if last.Bytes != int64(len(data)) || last.Total != int64(len(data)) || last.Resumed != 0 {
+		t.Errorf("invalid progress: %+v", last)
+	}
+	if FileExists(dest+".part") || FileExists(dest+".part.ifrange") {
+		t.Error("temporary files not removed")
+	}
+
+	// Resume partial download
+	FileSetBytes(dest+".part", data[:30000])
+	FileSetString(dest+".part.ifrange", etag)
+	ranges = nil
+	err = FileDownload(server.URL, dest, &FileDownloadOptions{
+		SHA256:   sha256,
+		Progress: func(p FileDownloadProgress) { last = p },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=30000-" || last.Resumed != 30000 {
+		t.Errorf("download not resumed: %v %+v", ranges, last)
+	}
+	if result, _ := FileGetBytes(dest); !bytes.Equal(result, data) {
+		t.Error("invalid resumed data")
+	}
+
+	// Changed file on server is downloaded completely
+	FileSetBytes(dest+".part", []byte("outdated"))
+	FileSetString(dest+".part.ifrange", `"v0"`)
+	err = FileDownload(server.URL, dest, &FileDownloadOptions{SHA256: sha256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := FileGetBytes(dest); !bytes.Equal(result, data) {
+		t.Error("invalid data after changed ETag")
+	}
+
+	// Checksum mismatch
+	err = FileDownload(server.URL, dest+".2", &FileDownloadOptions{MD5: "00000000000000000000000000000000"})
+	var checksumErr *FileDownloadChecksumError
+	if !errors.As(err, &checksumErr) || checksumErr.Algorithm != HashMD5 {
+		t.Errorf("expected *FileDownloadChecksumError, got %v", err)
+	}
+	if FileExists(dest+".2") || FileExists(dest+".2.part") {
+		t.Error("files kept after checksum mismatch")
+	}
+
+}
@@ -0,0 +1,167 @@
+package dry
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+/*
+EnvBind sets the fields of the struct pointed to by structPtr
+from environment variables.
+
+The variable name of a field is prefix followed by the name
+from the `env` struct tag, or if there is no tag, the field name
+converted with StringToUpperSnakeCase.
+Fields tagged with `env:"-"` and unexported fields are ignored.
+Options can follow the name separated by commas:
+
+	required  the variable must be set or have a default
+	inline    a struct field's fields are bound without an additional prefix
+
+A `default` tag provides the value if the variable is not set or empty.
+Slices are split by the separator from the `sep` tag, or "," if there is none.
+Nested struct fields are bound with their variable name plus "_" as prefix,
+anonymous embedded structs are inlined.
+Nil pointers to structs are allocated.
+Besides the basic kinds, time.Duration, url.URL and all types
+implementing encoding.TextUnmarshaler are supported.
+
+All problems are collected and returned as an ErrorList.
+
+Usage example:
+
+	type Config struct {
+		Port     int           `env:"PORT" default:"8080"`
+		Hosts    []string      `env:"HOSTS" sep:";"`
+		Timeout  time.Duration `default:"5s"`
+		Database struct {
+			URL url.URL `env:"URL,required"`
+		} `env:"DB"`
+	}
+
+	var config Config
+	// Reads APP_PORT, APP_HOSTS, APP_TIMEOUT, APP_DB_URL
+	err := dry.EnvBind(&config, "APP_")
+*/
+func EnvBind(structPtr any, prefix string) error {
+	return envBind(structPtr, prefix, os.LookupEnv)
+}
+
+// EnvBindMap works like EnvBind but takes the variables from env
+// instead of the environment of the process.
+// Useful in combination with EnvironMap or FileGetDotenv.
+func EnvBindMap(structPtr any, prefix string, env map[string]string) error {
+	return envBind(structPtr, prefix, func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+}
+
+func envBind(structPtr any, prefix string, lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(structPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("structPtr must be pointer to a struct, but is %T", structPtr)
+	}
+
+	var errs ErrorList
+	for _, field := range bindStructFields(v.Elem(), prefix, "", nil, nil) {
+		value, ok := lookup(field.Name)
+		if !ok || value == "" {
+			value, ok = field.Tag.Lookup("default")
+		}
+		if !ok {
+			if field.Required {
+				errs = append(errs, fmt.Errorf("required environment variable %s for field %s is not set", field.Name, field.Path))
+			}
+			continue
+		}
+		err := reflectSetFromString(field.Value, value, field.Tag.Get("sep"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("environment variable %s for field %s: %w", field.Name, field.Path, err))
+		}
+	}
+	return errs.Err()
+}
+
+// bindStructField is a bindable struct field found by bindStructFields
+type bindStructField struct {
+	// Name of the environment variable including prefix
+	Name string
+	// Path of the field like "Database.URL"
+	Path string
+	// Key of the field like ["Database", "URL"] with the names
+	// from `config` tags or Go field names, without inlined structs
+	Key      []string
+	Value    reflect.Value
+	Tag      reflect.StructTag
+	Required bool
+}
+
+// bindStructFields appends all bindable fields of the struct v to fields
+// using the naming rules documented at EnvBind.
+func bindStructFields(v reflect.Value, prefix, path string, key []string, fields []bindStructField) []bindStructField {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !ReflectStructFieldIsExported(structField) {
+			continue
+		}
+		tag := structField.Tag.Get("env")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = StringToUpperSnakeCase(structField.Name)
+		}
+		fieldPath := structField.Name
+		if path != "" {
+			fieldPath = path + "." + structField.Name
+		}
+		keyName, _, _ := strings.Cut(structField.Tag.Get("config"), ",")
+		if keyName == "" {
+			keyName = structField.Name
+		}
+		fieldKey := append(key[:len(key):len(key)], keyName)
+
+		fieldValue := v.Field(i)
+		fieldType := structField.Type
+		if fieldType.Kind() == reflect.Ptr && reflectIsStructToRecurse(fieldType.Elem()) {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldType.Elem()))
+			}
+			fieldValue = fieldValue.Elem()
+			fieldType = fieldType.Elem()
+		}
+		if reflectIsStructToRecurse(fieldType) {
+			nestedPrefix := prefix + name + "_"
+			if (structField.Anonymous && tag == "") || envTagHasOption(options, "inline") {
+				nestedPrefix = prefix
+				fieldKey = key
+			}
+			fields = bindStructFields(fieldValue, nestedPrefix, fieldPath, fieldKey, fields)
+			continue
+		}
+
+		fields = append(fields, bindStructField{
+			Name:     prefix + name,
+			Path:     fieldPath,
+			Key:      fieldKey,
+			Value:    fieldValue,
+			Tag:      structField.Tag,
+			Required: envTagHasOption(options, "required"),
+		})
+	}
+	return fields
+}
+
+func envTagHasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
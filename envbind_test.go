@@ -0,0 +1,48 @@
+package dry
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+type envBindTestConfig struct {
+	Port     int           `env:"PORT" default:"8080"`
+	Hosts    []string      `env:"HOSTS" sep:";"`
+	Timeout  time.Duration `default:"5s"`
+	Ignored  string        `env:"-"`
+	Database struct {
+		URL      url.URL `env:"URL,required"`
+		MaxConns uint8
+	} `env:"DB"`
+}
+
+func TestEnvBindMap(t *testing.T) {
+	var config envBindTestConfig
+	err := EnvBindMap(&config, "APP_", map[string]string{
+		"APP_HOSTS":        "a; b;c",
+		"APP_DB_URL":       "postgres://localhost/db",
+		"APP_DB_MAX_CONNS": "20",
+		"APP_IGNORED":      "x",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 8080 ||
+		len(config.Hosts) != 3 || config.Hosts[1] != "b" ||
+		config.Timeout != 5*time.Second ||
+		config.Ignored != "" ||
+		config.Database.URL.Host != "localhost" ||
+		config.Database.MaxConns != 20 {
+		t.Fatalf("Invalid values: %#v", config)
+	}
+
+	config = envBindTestConfig{}
+	err = EnvBindMap(&config, "APP_", map[string]string{
+		"APP_PORT":         "not a number",
+		"APP_DB_MAX_CONNS": "1000",
+	})
+	if errs := AsErrorList(err); len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got: %v", err)
+	}
+}
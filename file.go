@@ -5,12 +5,11 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/zlib"
-	"crypto/md5" //#nosec
+	"encoding/binary"
 	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
-	"hash/crc64"
 	"io"
 	"net/http"
 	"os"
@@ -50,6 +49,31 @@ func FileGetBytes(filenameOrURL string, timeout ...time.Duration) ([]byte, error
 	return os.ReadFile(filenameOrURL) //#nosec G304
 }
 
+// fileOpenReader opens filenameOrURL like FileGetBytes
+// but returns a reader for streaming the data.
+func fileOpenReader(filenameOrURL string, timeout ...time.Duration) (io.ReadCloser, error) {
+	if strings.Contains(filenameOrURL, "://") {
+		if strings.Index(filenameOrURL, "file://") == 0 {
+			filenameOrURL = filenameOrURL[len("file://"):]
+		} else {
+			client := http.DefaultClient
+			if len(timeout) > 0 {
+				client = &http.Client{Timeout: timeout[0]}
+			}
+			r, err := client.Get(filenameOrURL)
+			if err != nil {
+				return nil, err
+			}
+			if r.StatusCode < 200 || r.StatusCode > 299 {
+				r.Body.Close()
+				return nil, fmt.Errorf("%d: %s", r.StatusCode, http.StatusText(r.StatusCode))
+			}
+			return r.Body, nil
+		}
+	}
+	return os.Open(filenameOrURL) //#nosec G304
+}
+
 func FileSetBytes(filename string, data []byte) error {
 	return os.WriteFile(filename, data, 0644)
 }
@@ -191,7 +215,12 @@ func FileGetNonEmptyLines(filenameOrURL string, timeout ...time.Duration) (lines
 	if err != nil {
 		return nil, err
 	}
+	return bytesNonEmptyLines(data), nil
+}
 
+// bytesNonEmptyLines splits data at LF, CRLF and CR line breaks
+// and omits empty lines.
+func bytesNonEmptyLines(data []byte) (lines []string) {
 	lastR := -1
 	lastN := -1
 
@@ -218,7 +247,7 @@ func FileGetNonEmptyLines(filenameOrURL string, timeout ...time.Duration) (lines
 		lines = append(lines, l)
 	}
 
-	return lines, nil
+	return lines
 }
 
 func FileGetConfig(filenameOrURL string, timeout ...time.Duration) (map[string]string, error) {
@@ -248,9 +277,12 @@ func FileGetConfig(filenameOrURL string, timeout ...time.Duration) (map[string]s
 	return config, nil
 }
 
+// FileSetConfig writes config as key=value lines sorted by key.
+// Use ConfigFile to preserve the order and comments of an existing file.
 func FileSetConfig(filename string, config map[string]string) error {
 	var buffer bytes.Buffer
-	for key, value := range config {
+	for _, key := range StringMapSortedKeys(config) {
+		value := config[key]
 		if strings.ContainsRune(key, '=') {
 			return fmt.Errorf("Key '%s' contains '='", key)
 		}
@@ -263,6 +295,7 @@ func FileSetConfig(filename string, config map[string]string) error {
 // In case of a network file, the whole file is read.
 // In case of a local file, the last 64kb are read,
 // so if the last line is longer than 64kb it is not returned completely.
+// Use FileGetLastLines for lines of any length.
 // The first optional timeout is used for network files only.
 func FileGetLastLine(filenameOrURL string, timeout ...time.Duration) (line string, err error) {
 	if strings.Index(filenameOrURL, "file://") == 0 {
@@ -302,40 +335,102 @@ func FileGetLastLine(filenameOrURL string, timeout ...time.Duration) (line strin
 	return string(data[pos+1:]), nil
 }
 
-// func FileTail(filenameOrURL string, numLines int, timeout ...time.Duration) (lines []string, err error) {
-// 	if strings.Index(filenameOrURL, "file://") == 0 {
-// 		filenameOrURL = filenameOrURL[len("file://"):]
-// 	} else if strings.Contains(filenameOrURL, "://") {
-// 		data, err := FileGetBytes(filenameOrURL, timeout...)
-// 		if err != nil {
-// 			return nil, err
-// 		}
-// 		lines, _ := BytesTail(data, numLines)
-// 		return lines, nil
-// 	}
-
-// 	// data := make([]byte, 0, 1024*256)
-
-// 	// file, err := os.Open(filenameOrURL)
-// 	// if err != nil {
-// 	// 	return nil, err
-// 	// }
-// 	// defer file.Close()
-// 	// info, err := file.Stat()
-// 	// if err != nil {
-// 	// 	return nil, err
-// 	// }
-// 	// if start := info.Size() - 64*1024; start > 0 {
-// 	// 	file.Seek(start, os.SEEK_SET)
-// 	// }
-// 	// data, err = io.ReadAll(file)
-// 	// if err != nil {
-// 	// 	return nil, err
-// 	// }
-
-// 	return lines, nil
-
-// }
+/*
+FileGetLastLines returns the last n lines of a file
+like FileFollow with FileFollowOptions.LastLines does.
+Lines end with LF, CRLF is handled like LF,
+and a line break at the end of the file does not start another line.
+Local files are read backwards in chunks until n complete lines are found.
+For URLs, HTTP Range requests are used if the server supports them,
+else the whole file is read.
+The first optional timeout is used for network files only.
+*/
+func FileGetLastLines(filenameOrURL string, n int, timeout ...time.Duration) (lines []string, err error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if strings.Index(filenameOrURL, "file://") == 0 {
+		filenameOrURL = filenameOrURL[len("file://"):]
+	}
+
+	if strings.Contains(filenameOrURL, "://") {
+		client := http.DefaultClient
+		if len(timeout) > 0 {
+			client = &http.Client{Timeout: timeout[0]}
+		}
+		reader, size, err := newHTTPRangeReaderAt(client, filenameOrURL)
+		if err != nil {
+			return nil, err
+		}
+		if reader == nil {
+			// Server does not support range requests
+			data, err := FileGetBytes(filenameOrURL, timeout...)
+			if err != nil {
+				return nil, err
+			}
+			return readerLastLines(bytes.NewReader(data), int64(len(data)), n)
+		}
+		return readerLastLines(reader, size, n)
+	}
+
+	file, err := os.Open(filenameOrURL) //#nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //#nosec G307
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return readerLastLines(file, info.Size(), n)
+}
+
+// httpRangeReaderAt reads parts of a URL via HTTP Range requests
+type httpRangeReaderAt struct {
+	client *http.Client
+	url    string
+}
+
+// newHTTPRangeReaderAt returns a nil reader if the server
+// does not announce support of range requests for url.
+func newHTTPRangeReaderAt(client *http.Client, url string) (reader *httpRangeReaderAt, size int64, err error) {
+	response, err := client.Head(url)
+	if err != nil {
+		return nil, 0, err
+	}
+	response.Body.Close() //#nosec G104
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, 0, fmt.Errorf("%d: %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+	if response.Header.Get("Accept-Ranges") != "bytes" || response.ContentLength < 0 {
+		return nil, 0, nil
+	}
+	return &httpRangeReaderAt{client: client, url: url}, response.ContentLength, nil
+}
+
+func (r *httpRangeReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	request, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1))
+	response, err := r.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("%d: %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+	n, err = io.ReadFull(response.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
 
 // FileTimeModified returns the modified time of a file,
 // or the zero time value in case of an error.
@@ -396,6 +491,7 @@ func FileTouch(filename string) error {
 // FileMD5String returns the hex encoded MD5 hash of the file contents.
 // WARNING: MD5 is cryptographically broken and should NOT be used for security purposes.
 // This function is suitable for checksums, cache keys, and other non-security applications only.
+// See FileHash for other algorithms.
 func FileMD5String(filenameOrURL string) (string, error) {
 	sum, err := FileMD5Bytes(filenameOrURL)
 	if err != nil {
@@ -408,29 +504,17 @@ func FileMD5String(filenameOrURL string) (string, error) {
 // WARNING: MD5 is cryptographically broken and should NOT be used for security purposes.
 // This function is suitable for checksums, cache keys, and other non-security applications only.
 func FileMD5Bytes(filenameOrURL string) ([]byte, error) {
-	data, err := FileGetBytes(filenameOrURL)
-	if err != nil {
-		return nil, err
-	}
-	hash := md5.New() //#nosec
-	_, err = io.Copy(hash, bytes.NewBuffer(data))
-	if err != nil {
-		return nil, err
-	}
-	return hash.Sum(nil), nil
+	return FileHash(filenameOrURL, HashMD5)
 }
 
-var crc64Table *crc64.Table
-
+// FileCRC64 returns the CRC64 checksum with ECMA polynomial
+// of the file contents.
 func FileCRC64(filenameOrURL string) (uint64, error) {
-	data, err := FileGetBytes(filenameOrURL)
+	sum, err := FileHash(filenameOrURL, HashCRC64)
 	if err != nil {
 		return 0, err
 	}
-	if crc64Table == nil {
-		crc64Table = crc64.MakeTable(crc64.ECMA)
-	}
-	return crc64.Checksum(data, crc64Table), nil
+	return binary.BigEndian.Uint64(sum), nil
 }
 
 func FileGetInflate(filenameOrURL string) ([]byte, error) {
@@ -605,6 +689,7 @@ func FileCopy(source string, dest string) (err error) {
 
 // FileCopyDir recursively copies a directory tree, attempting to preserve permissions.
 // Source directory must exist, destination directory must *not* exist.
+// See CopyDir for more options.
 // Based on Jaybill McCarthy's code which can be found at http://jayblog.jaybill.com/post/id/26
 func FileCopyDir(source string, dest string) (err error) {
 	// get properties of source dir
@@ -650,3 +735,27 @@ type FileCopyError struct {
 func (e *FileCopyError) Error() string {
 	return e.What
 }
+
+// FileParseError is returned for syntax errors in parsed files
+// like dotenv or INI files.
+type FileParseError struct {
+	Filename string
+	Line     int
+	Message  string
+}
+
+func (e *FileParseError) Error() string {
+	if e.Filename == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.Filename, e.Line, e.Message)
+}
+
+// fileParseErrorWithFilename sets the filename of err
+// if it is a *FileParseError and returns err.
+func fileParseErrorWithFilename(err error, filename string) error {
+	if parseErr, ok := err.(*FileParseError); ok {
+		parseErr.Filename = filename
+	}
+	return err
+}
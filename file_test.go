@@ -1,8 +1,15 @@
 package dry
 
 import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_FileGetString(t *testing.T) {
@@ -36,3 +43,65 @@ func Test_FileIsDir(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_FileGetLastLines(t *testing.T) {
+	long := strings.Repeat("x", 100*1024)
+	data := "first\r\n\r\nsecond\n" + long + "\r\nthird\n\n"
+	filename := filepath.Join(t.TempDir(), "lines.txt")
+	FileSetString(filename, data)
+
+	all := []string{"first", "", "second", long, "third", ""}
+	for n := 1; n <= len(all)+1; n++ {
+		lines, err := FileGetLastLines(filename, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := all[max(len(all)-n, 0):]
+		if !reflect.DeepEqual(lines, expected) {
+			t.Errorf("FileGetLastLines(%d) returned %d lines, expected %d", n, len(lines), len(expected))
+		}
+	}
+
+	// Same lines as FileFollow with LastLines
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var followed []string
+	for line, err := range FileFollow(ctx, filename, &FileFollowOptions{LastLines: 4}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		followed = append(followed, line)
+		if len(followed) == 4 {
+			cancel()
+		}
+	}
+	if !reflect.DeepEqual(followed, all[2:]) {
+		t.Errorf("FileFollow returned different last lines than FileGetLastLines")
+	}
+
+	ranges := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranges++
+		}
+		if r.URL.Path == "/noranges" {
+			w.Write([]byte(data))
+			return
+		}
+		http.ServeContent(w, r, "lines.txt", time.Time{}, bytes.NewReader([]byte(data)))
+	}))
+	defer server.Close()
+
+	for _, url := range []string{server.URL + "/ranges", server.URL + "/noranges"} {
+		lines, err := FileGetLastLines(url, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(lines, all[len(all)-2:]) {
+			t.Errorf("%s: invalid lines", url)
+		}
+	}
+	if ranges == 0 {
+		t.Error("no range requests used")
+	}
+}
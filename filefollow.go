@@ -0,0 +1,250 @@
+package dry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"time"
+)
+
+// FileFollowOptions configures FileFollow.
+// A nil *FileFollowOptions is valid and uses the defaults.
+type FileFollowOptions struct {
+	// LastLines of the existing file content are emitted first,
+	// if zero only lines appended after the start are emitted
+	LastLines int
+	// FromStart emits all existing lines first, overrides LastLines
+	FromStart bool
+	// PollInterval for checking the file for new data
+	// and rotation, defaults to 250ms
+	PollInterval time.Duration
+}
+
+/*
+FileFollow returns an iterator over lines appended to the file at path
+like "tail -F" until ctx is canceled or the loop is stopped.
+Lines are returned without line endings, CRLF is handled like LF.
+An incomplete last line is only emitted after its line break was written,
+or when the file is rotated.
+
+Log rotation is detected when the file is truncated,
+or when path refers to a different file after a rename,
+in which case the new file is followed from its start.
+While path does not exist during rotation, the old file is
+still read and the new file is waited for.
+
+An error is yielded if the file can't be opened initially,
+later errors are yielded and following continues.
+
+Usage example:
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	for line, err := range dry.FileFollow(ctx, "/var/log/app.log", &dry.FileFollowOptions{LastLines: 10}) {
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		fmt.Println(line)
+	}
+*/
+func FileFollow(ctx context.Context, path string, opts *FileFollowOptions) iter.Seq2[string, error] {
+	if opts == nil {
+		opts = &FileFollowOptions{}
+	}
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 250 * time.Millisecond
+	}
+	return func(yield func(string, error) bool) {
+		file, err := os.Open(path) //#nosec G304
+		if err != nil {
+			yield("", err)
+			return
+		}
+		defer func() { file.Close() }() //#nosec G104
+
+		var offset int64
+		switch {
+		case opts.FromStart:
+		case opts.LastLines > 0:
+			offset, err = fileTailOffset(file, opts.LastLines)
+		default:
+			offset, err = file.Seek(0, io.SeekEnd)
+		}
+		if err == nil {
+			_, err = file.Seek(offset, io.SeekStart)
+		}
+		if err != nil {
+			yield("", err)
+			return
+		}
+
+		var (
+			pending []byte
+			buf     = make([]byte, 32*1024)
+			ticker  = time.NewTicker(pollInterval)
+		)
+		defer ticker.Stop()
+		for ctx.Err() == nil {
+			// Read all available data
+			for {
+				n, err := file.Read(buf)
+				offset += int64(n)
+				pending = append(pending, buf[:n]...)
+				for {
+					i := bytes.IndexByte(pending, '\n')
+					if i == -1 {
+						break
+					}
+					if !yield(fileLine(pending[:i]), nil) {
+						return
+					}
+					pending = pending[i+1:]
+				}
+				if err == io.EOF || (err == nil && n == 0) {
+					break
+				}
+				if err != nil {
+					if !yield("", err) {
+						return
+					}
+					break
+				}
+			}
+			if len(pending) == 0 {
+				pending = nil // release memory of long lines
+			}
+
+			// Check for rotation
+			pathInfo, err := os.Stat(path)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				// Wait for the new file
+			case err != nil:
+				if !yield("", err) {
+					return
+				}
+			default:
+				fileInfo, err := file.Stat()
+				if err != nil {
+					if !yield("", err) {
+						return
+					}
+					break
+				}
+				if !os.SameFile(fileInfo, pathInfo) {
+					newFile, err := os.Open(path) //#nosec G304
+					if err != nil {
+						if !yield("", err) {
+							return
+						}
+						break
+					}
+					if len(pending) > 0 && !yield(fileLine(pending), nil) {
+						newFile.Close() //#nosec G104
+						return
+					}
+					file.Close() //#nosec G104
+					file, offset, pending = newFile, 0, nil
+					continue // read the new file without waiting
+				}
+				if pathInfo.Size() < offset {
+					// Truncated
+					offset, err = file.Seek(0, io.SeekStart)
+					pending = nil
+					if err != nil && !yield("", err) {
+						return
+					}
+					continue
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// fileTailOffset returns the offset of the start of the last n lines
+// of file by reading backwards in chunks.
+// A line break at the end of the file does not start another line.
+func fileTailOffset(file *os.File, n int) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return readerTailOffset(file, info.Size(), n)
+}
+
+// fileLine returns a line without its LF line break as string.
+// This is the definition of a line used by FileFollow and FileGetLastLines:
+// lines end with LF and a CR before the LF is removed.
+func fileLine(line []byte) string {
+	return string(bytes.TrimSuffix(line, []byte{'\r'}))
+}
+
+// readerLastLines returns the last n lines of the data with size in reader
+// using readerTailOffset.
+func readerLastLines(reader io.ReaderAt, size int64, n int) ([]string, error) {
+	offset, err := readerTailOffset(reader, size, n)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size-offset)
+	_, err = reader.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	data = bytes.TrimSuffix(data, []byte{'\n'})
+	lines := bytes.Split(data, []byte{'\n'})
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = fileLine(line)
+	}
+	return result, nil
+}
+
+// readerTailOffset returns the offset of the start of the last n lines
+// of the data with size in reader.
+func readerTailOffset(reader io.ReaderAt, size int64, n int) (int64, error) {
+	const chunkSize = 32 * 1024
+	if n <= 0 {
+		return size, nil
+	}
+	buf := make([]byte, chunkSize)
+	end := size
+	first := true // ignore line break at the end of the data
+	for end > 0 {
+		start := max(end-chunkSize, 0)
+		chunk := buf[:end-start]
+		_, err := reader.ReadAt(chunk, start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if first && chunk[len(chunk)-1] == '\n' {
+			chunk = chunk[:len(chunk)-1]
+		}
+		first = false
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] == '\n' {
+				n--
+				if n == 0 {
+					return start + int64(i) + 1, nil
+				}
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
@@ -0,0 +1,91 @@
+package dry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileFollow(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	FileSetString(filename, "one\ntwo\r\nthree\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		opts := &FileFollowOptions{LastLines: 2, PollInterval: 5 * time.Millisecond}
+		for line, err := range FileFollow(ctx, filename, opts) {
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			lines <- line
+		}
+	}()
+	expect := func(expected ...string) {
+		t.Helper()
+		var received []string
+		for range expected {
+			select {
+			case line := <-lines:
+				received = append(received, line)
+			case <-ctx.Done():
+				t.Fatalf("timeout after receiving %q, expected %q", received, expected)
+			}
+		}
+		if !reflect.DeepEqual(received, expected) {
+			t.Fatalf("received %q, expected %q", received, expected)
+		}
+	}
+
+	expect("two", "three")
+
+	FileAppendString(filename, "four\nfi")
+	expect("four")
+	FileAppendString(filename, "ve\n")
+	expect("five")
+
+	// Rotation by rename
+	os.Rename(filename, filename+".1")
+	FileAppendString(filename+".1", "six\n")
+	time.Sleep(20 * time.Millisecond)
+	FileSetString(filename, "seven\n")
+	expect("six", "seven")
+
+	// Rotation by truncation
+	FileSetString(filename, "")
+	time.Sleep(20 * time.Millisecond)
+	FileAppendString(filename, "eight\n")
+	expect("eight")
+
+	cancel()
+	for range lines {
+	}
+}
+
+func Test_readerTailOffset(t *testing.T) {
+	data := "a\nbb\r\n" + strings.Repeat("c", 100*1024) + "\nd\n"
+	tests := []struct {
+		n        int
+		expected int64
+	}{
+		{0, int64(len(data))},
+		{1, int64(len(data) - 2)},
+		{2, 6},
+		{3, 2},
+		{4, 0},
+		{10, 0},
+	}
+	for _, test := range tests {
+		offset, err := readerTailOffset(strings.NewReader(data), int64(len(data)), test.n)
+		if err != nil || offset != test.expected {
+			t.Errorf("readerTailOffset(%d) = %d, %v, expected %d", test.n, offset, err, test.expected)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package dry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// FileLockMode is the mode of an advisory file lock.
+type FileLockMode int
+
+const (
+	// FileLockExclusive allows only one holder of the lock
+	FileLockExclusive FileLockMode = iota
+	// FileLockShared allows multiple holders of shared locks
+	// but no exclusive holder at the same time
+	FileLockShared
+)
+
+// ErrFileLockTimeout is returned by FileTryLock
+// if the lock could not be acquired within the timeout.
+var ErrFileLockTimeout = errors.New("timeout while waiting for file lock")
+
+// fileLockPollInterval is the interval of lock attempts by FileTryLock
+const fileLockPollInterval = 10 * time.Millisecond
+
+/*
+LockedFile is an open file holding an advisory lock
+that coordinates access between processes and goroutines
+using the locking functions of this package.
+Advisory locks don't prevent access by code that doesn't lock.
+Locks are only supported on Linux, on other systems
+the locking functions return an error wrapping errors.ErrUnsupported.
+
+Usage example:
+
+	lock, err := dry.FileLock("counter.txt")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	// Read and write counter.txt via lock.File()
+*/
+type LockedFile struct {
+	file *os.File
+	mode FileLockMode
+}
+
+// FileLock opens or creates the file at path and blocks
+// until an exclusive lock for it is acquired.
+func FileLock(path string) (*LockedFile, error) {
+	return fileLock(path, FileLockExclusive, -1)
+}
+
+// FileRLock opens or creates the file at path and blocks
+// until a shared lock for it is acquired.
+func FileRLock(path string) (*LockedFile, error) {
+	return fileLock(path, FileLockShared, -1)
+}
+
+// FileTryLock opens or creates the file at path and tries to acquire
+// a lock with mode until timeout, a timeout of zero tries only once.
+// ErrFileLockTimeout is returned if the lock is held by somebody else.
+func FileTryLock(path string, mode FileLockMode, timeout time.Duration) (*LockedFile, error) {
+	return fileLock(path, mode, max(timeout, 0))
+}
+
+// fileLock blocks if timeout is negative
+func fileLock(path string, mode FileLockMode, timeout time.Duration) (*LockedFile, error) {
+	flag := os.O_RDWR | os.O_CREATE
+	if mode == FileLockShared {
+		flag = os.O_RDONLY | os.O_CREATE
+	}
+	file, err := os.OpenFile(path, flag, 0644) //#nosec G304
+	if err != nil {
+		return nil, err
+	}
+	err = fileLockWait(file, mode, timeout)
+	if err != nil {
+		file.Close() //#nosec G104
+		return nil, err
+	}
+	return &LockedFile{file: file, mode: mode}, nil
+}
+
+func fileLockWait(file *os.File, mode FileLockMode, timeout time.Duration) error {
+	if timeout < 0 {
+		return fileLockFd(file, mode, true)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := fileLockFd(file, mode, false)
+		if !errors.Is(err, errFileLockBusy) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w %s", ErrFileLockTimeout, file.Name())
+		}
+		time.Sleep(min(fileLockPollInterval, time.Until(deadline)+time.Millisecond))
+	}
+}
+
+// File returns the locked file.
+// Shared locked files are opened read only.
+func (l *LockedFile) File() *os.File {
+	return l.file
+}
+
+// Mode returns the mode of the lock.
+func (l *LockedFile) Mode() FileLockMode {
+	return l.mode
+}
+
+// Unlock releases the lock and closes the file.
+func (l *LockedFile) Unlock() error {
+	err := fileUnlockFd(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// fileAppendLocked opens filename for appending,
+// and calls write while holding an exclusive lock
+func fileAppendLocked(filename string, write func(io.Writer) error) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644) //#nosec G304
+	if err != nil {
+		return err
+	}
+	defer file.Close() //#nosec G307
+	err = fileLockFd(file, FileLockExclusive, true)
+	if err != nil {
+		return err
+	}
+	defer fileUnlockFd(file) //#nosec G104 -- closing the file also releases the lock
+	return write(file)
+}
+
+// FileAppendBytesLocked appends data to filename like FileAppendBytes
+// while holding an exclusive lock on the file.
+func FileAppendBytesLocked(filename string, data []byte) error {
+	return fileAppendLocked(filename, func(writer io.Writer) error {
+		_, err := writer.Write(data)
+		return err
+	})
+}
+
+// FileAppendStringLocked appends data to filename like FileAppendString
+// while holding an exclusive lock on the file.
+func FileAppendStringLocked(filename string, data string) error {
+	return FileAppendBytesLocked(filename, []byte(data))
+}
+
+// FileAppendPrintfLocked appends formatted text to filename like
+// FileAppendPrintf while holding an exclusive lock on the file.
+func FileAppendPrintfLocked(filename, format string, args ...any) error {
+	return fileAppendLocked(filename, func(writer io.Writer) error {
+		_, err := fmt.Fprintf(writer, format, args...)
+		return err
+	})
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package dry
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var errFileLockBusy = syscall.EWOULDBLOCK
+
+func fileLockFd(file *os.File, mode FileLockMode, block bool) error {
+	how := syscall.LOCK_EX
+	if mode == FileLockShared {
+		how = syscall.LOCK_SH
+	}
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		err := syscall.Flock(int(file.Fd()), how)
+		if errors.Is(err, syscall.EINTR) {
+			continue
+		}
+		if err != nil {
+			return &os.PathError{Op: "flock", Path: file.Name(), Err: err}
+		}
+		return nil
+	}
+}
+
+func fileUnlockFd(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	if err != nil {
+		return &os.PathError{Op: "flock", Path: file.Name(), Err: err}
+	}
+	return nil
+}
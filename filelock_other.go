@@ -0,0 +1,19 @@
+//go:build !linux
+
+package dry
+
+import (
+	"errors"
+	"os"
+)
+
+var errFileLockBusy = errors.New("file is locked")
+
+// fileLockFd is not supported on systems other than Linux.
+func fileLockFd(file *os.File, mode FileLockMode, block bool) error {
+	return &os.PathError{Op: "flock", Path: file.Name(), Err: errors.ErrUnsupported}
+}
+
+func fileUnlockFd(file *os.File) error {
+	return &os.PathError{Op: "flock", Path: file.Name(), Err: errors.ErrUnsupported}
+}
@@ -0,0 +1,99 @@
+package dry
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileLock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lock")
+	lock, err := FileLock(filename)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FileTryLock(filename, FileLockShared, 20*time.Millisecond)
+	if !errors.Is(err, ErrFileLockTimeout) {
+		t.Errorf("expected ErrFileLockTimeout, got %v", err)
+	}
+
+	acquired := make(chan *LockedFile)
+	go func() {
+		lock, err := FileTryLock(filename, FileLockExclusive, 5*time.Second)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- lock
+	}()
+	time.Sleep(20 * time.Millisecond)
+	err = lock.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock = <-acquired
+	if lock == nil {
+		t.FailNow()
+	}
+	lock.Unlock()
+
+	shared1, err := FileRLock(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shared1.Unlock()
+	shared2, err := FileTryLock(filename, FileLockShared, 0)
+	if err != nil {
+		t.Fatal("shared locks should not exclude each other:", err)
+	}
+	defer shared2.Unlock()
+	_, err = FileTryLock(filename, FileLockExclusive, 0)
+	if !errors.Is(err, ErrFileLockTimeout) {
+		t.Errorf("expected ErrFileLockTimeout, got %v", err)
+	}
+}
+
+func TestFileAppendLocked(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "append.txt")
+	if err := FileAppendStringLocked(filename, ""); errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+
+	const writers, lines = 8, 50
+	line := strings.Repeat("x", 1000)
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < lines; i++ {
+				err := FileAppendPrintfLocked(filename, "%d %s\n", w, line)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	result, err := FileGetNonEmptyLines(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != writers*lines {
+		t.Errorf("expected %d lines, got %d", writers*lines, len(result))
+	}
+	for _, l := range result {
+		if !strings.HasSuffix(l, " "+line) || len(l) != len(fmt.Sprintf("0 %s", line)) {
+			t.Fatalf("corrupted line: %q", l)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package dry
+
+import (
+	"crypto/md5"  //#nosec
+	"crypto/sha1" //#nosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// HashAlgorithm names a hash algorithm supported by FileHash,
+// BytesHash and StringHash.
+type HashAlgorithm string
+
+const (
+	// HashMD5 is cryptographically broken,
+	// use it only for checksums and cache keys
+	HashMD5 HashAlgorithm = "md5"
+	// HashSHA1 is cryptographically broken,
+	// use it only for checksums and cache keys
+	HashSHA1       HashAlgorithm = "sha1"
+	HashSHA256     HashAlgorithm = "sha256"
+	HashSHA512     HashAlgorithm = "sha512"
+	HashBLAKE2b256 HashAlgorithm = "blake2b-256"
+	HashBLAKE2b512 HashAlgorithm = "blake2b-512"
+	// HashCRC32 uses the IEEE polynomial
+	HashCRC32 HashAlgorithm = "crc32"
+	// HashCRC64 uses the ECMA polynomial like FileCRC64
+	HashCRC64 HashAlgorithm = "crc64"
+	// HashXXH64 is the fast non-cryptographic xxHash64 with seed zero
+	HashXXH64 HashAlgorithm = "xxh64"
+)
+
+var crc64ECMATable = crc64.MakeTable(crc64.ECMA)
+
+// New returns a new hash.Hash for the algorithm.
+// Checksums like CRC32 return their big endian bytes as sum.
+func (algo HashAlgorithm) New() (hash.Hash, error) {
+	switch algo {
+	case HashMD5:
+		return md5.New(), nil //#nosec
+	case HashSHA1:
+		return sha1.New(), nil //#nosec
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashSHA512:
+		return sha512.New(), nil
+	case HashBLAKE2b256:
+		return blake2b.New256(nil)
+	case HashBLAKE2b512:
+		return blake2b.New512(nil)
+	case HashCRC32:
+		return crc32.NewIEEE(), nil
+	case HashCRC64:
+		return crc64.New(crc64ECMATable), nil
+	case HashXXH64:
+		return newXXH64(), nil
+	}
+	return nil, fmt.Errorf("unsupported hash algorithm %q", algo)
+}
+
+// FileHash returns the hash of the file contents
+// which are streamed instead of being loaded into memory.
+func FileHash(filenameOrURL string, algo HashAlgorithm, timeout ...time.Duration) ([]byte, error) {
+	sums, err := FileHashes(filenameOrURL, []HashAlgorithm{algo}, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	return sums[algo], nil
+}
+
+/*
+FileHashes returns multiple hashes of the file contents
+computed in a single pass over the streamed data.
+
+Usage example:
+
+	sums, err := dry.FileHashes("release.tar.gz", []dry.HashAlgorithm{dry.HashSHA256, dry.HashMD5})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%x\n", sums[dry.HashSHA256])
+*/
+func FileHashes(filenameOrURL string, algos []HashAlgorithm, timeout ...time.Duration) (map[HashAlgorithm][]byte, error) {
+	reader, err := fileOpenReader(filenameOrURL, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close() //#nosec G307
+	return ReaderHashes(reader, algos...)
+}
+
+// ReaderHashes returns the hashes of all data read from reader
+// computed in a single pass.
+func ReaderHashes(reader io.Reader, algos ...HashAlgorithm) (map[HashAlgorithm][]byte, error) {
+	hashes := make(map[HashAlgorithm]hash.Hash, len(algos))
+	writers := make([]io.Writer, 0, len(algos))
+	for _, algo := range algos {
+		if _, ok := hashes[algo]; ok {
+			continue
+		}
+		h, err := algo.New()
+		if err != nil {
+			return nil, err
+		}
+		hashes[algo] = h
+		writers = append(writers, h)
+	}
+	_, err := io.Copy(io.MultiWriter(writers...), reader)
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[HashAlgorithm][]byte, len(hashes))
+	for algo, h := range hashes {
+		sums[algo] = h.Sum(nil)
+	}
+	return sums, nil
+}
+
+// BytesHash returns the hash of data.
+// It panics for unsupported algorithms.
+func BytesHash(data []byte, algo HashAlgorithm) []byte {
+	h, err := algo.New()
+	if err != nil {
+		panic(err)
+	}
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// StringHash returns the hex encoded hash of str.
+// It panics for unsupported algorithms.
+func StringHash(str string, algo HashAlgorithm) string {
+	return hex.EncodeToString(BytesHash([]byte(str), algo))
+}
@@ -0,0 +1,72 @@
+package dry
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStringHash(t *testing.T) {
+	tests := []struct {
+		algo     HashAlgorithm
+		data     string
+		expected string
+	}{
+		{HashMD5, "abc", "900150983cd24fb0d6963f7d28e17f72"},
+		{HashSHA1, "abc", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{HashSHA256, "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{HashBLAKE2b256, "", "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8"},
+		{HashBLAKE2b512, "abc", "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{HashCRC32, "abc", "352441c2"},
+		{HashXXH64, "", "ef46db3751d8e999"},
+		{HashXXH64, "abc", "44bc2cf5ad770999"},
+		{HashXXH64, "Nobody inspects the spammish repetition", "fbcea83c8a378bf1"},
+		{HashXXH64, strings.Repeat("\x00", 100), "17bb1103c92c502f"},
+	}
+	for _, test := range tests {
+		if result := StringHash(test.data, test.algo); result != test.expected {
+			t.Errorf("%s(%q) = %s, expected %s", test.algo, test.data, result, test.expected)
+		}
+	}
+}
+
+func TestHashChunkedWrites(t *testing.T) {
+	data := make([]byte, 768)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	// Writes not aligned to the block size
+	for _, algo := range []HashAlgorithm{HashBLAKE2b256, HashXXH64} {
+		h, _ := algo.New()
+		for i := 0; i < len(data); i += 7 {
+			h.Write(data[i:min(i+7, len(data))])
+		}
+		if sum := h.Sum(nil); string(sum) != string(BytesHash(data, algo)) {
+			t.Errorf("%s: chunked writes produce different sum", algo)
+		}
+	}
+}
+
+func TestFileHashes(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "data.txt")
+	FileSetString(filename, "abc")
+
+	sums, err := FileHashes(filename, []HashAlgorithm{HashSHA256, HashMD5, HashSHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != 2 || hex.EncodeToString(sums[HashMD5]) != StringHash("abc", HashMD5) {
+		t.Errorf("invalid sums: %x", sums)
+	}
+
+	crc, err := FileCRC64(filename)
+	if err != nil || crc != 0x2cd8094a1a277627 {
+		t.Errorf("invalid CRC64: %x, %v", crc, err)
+	}
+
+	_, err = FileHash(filename, "unknown")
+	if err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+}
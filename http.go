@@ -2,6 +2,8 @@ package dry
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -12,13 +14,72 @@ import (
 	"strings"
 )
 
+// wrappedResponseWriter compresses the response with encoding.
+// The Content-Encoding header is set and the compression writer
+// is created with the first Write or WriteHeader call,
+// so that a handler can still respond with an error status
+// if it fails before writing anything.
 type wrappedResponseWriter struct {
-	io.Writer
 	http.ResponseWriter
+	encoding string
+	gzip     *gzip.Writer
+	deflate  *flate.Writer
 }
 
-func (wrapped wrappedResponseWriter) Write(data []byte) (int, error) {
-	return wrapped.Writer.Write(data)
+func (wrapped *wrappedResponseWriter) writer() io.Writer {
+	switch {
+	case wrapped.gzip != nil:
+		return wrapped.gzip
+	case wrapped.deflate != nil:
+		return wrapped.deflate
+	}
+	wrapped.Header().Set("Content-Encoding", wrapped.encoding)
+	if wrapped.encoding == "gzip" {
+		wrapped.gzip = Gzip.GetWriter(wrapped.ResponseWriter)
+		return wrapped.gzip
+	}
+	wrapped.deflate = Deflate.GetWriter(wrapped.ResponseWriter)
+	return wrapped.deflate
+}
+
+func (wrapped *wrappedResponseWriter) Write(data []byte) (int, error) {
+	return wrapped.writer().Write(data)
+}
+
+func (wrapped *wrappedResponseWriter) WriteHeader(statusCode int) {
+	if statusCode >= http.StatusOK && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified {
+		wrapped.writer()
+	}
+	wrapped.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Flush implements http.Flusher by flushing the compression
+// writer before the wrapped http.ResponseWriter
+func (wrapped *wrappedResponseWriter) Flush() {
+	switch {
+	case wrapped.gzip != nil:
+		wrapped.gzip.Flush() //#nosec G104
+	case wrapped.deflate != nil:
+		wrapped.deflate.Flush() //#nosec G104
+	}
+	if flusher, ok := wrapped.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter for http.ResponseController
+func (wrapped *wrappedResponseWriter) Unwrap() http.ResponseWriter {
+	return wrapped.ResponseWriter
+}
+
+// close finishes the compression if anything was written
+func (wrapped *wrappedResponseWriter) close() {
+	switch {
+	case wrapped.gzip != nil:
+		Gzip.ReturnWriter(wrapped.gzip)
+	case wrapped.deflate != nil:
+		Deflate.ReturnWriter(wrapped.deflate)
+	}
 }
 
 // HTTPCompressHandlerFunc wraps a http.HandlerFunc so that the response gets
@@ -31,6 +92,8 @@ func HTTPCompressHandlerFunc(handlerFunc http.HandlerFunc) http.HandlerFunc {
 
 // HTTPCompressHandler wraps a http.Handler so that the response gets
 // gzip or deflate compressed if the Accept-Encoding header of the request allows it.
+// The Content-Encoding header is set with the first Write or WriteHeader call
+// of the wrapped handler, nothing is written if the handler writes nothing.
 type HTTPCompressHandler struct {
 	http.Handler
 }
@@ -44,21 +107,27 @@ func NewHTTPCompressHandlerFromFunc(handler http.HandlerFunc) *HTTPCompressHandl
 }
 
 func (h *HTTPCompressHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
-	accept := request.Header.Get("Accept-Encoding")
-	if strings.Contains(accept, "gzip") {
-		response.Header().Set("Content-Encoding", "gzip")
-		writer := Gzip.GetWriter(response)
-		defer Gzip.ReturnWriter(writer)
-		response = wrappedResponseWriter{Writer: writer, ResponseWriter: response}
-	} else if strings.Contains(accept, "deflate") {
-		response.Header().Set("Content-Encoding", "deflate")
-		writer := Deflate.GetWriter(response)
-		defer Deflate.ReturnWriter(writer)
-		response = wrappedResponseWriter{Writer: writer, ResponseWriter: response}
+	if encoding := httpCompressEncoding(request); encoding != "" {
+		wrapped := &wrappedResponseWriter{ResponseWriter: response, encoding: encoding}
+		defer wrapped.close()
+		response = wrapped
 	}
 	h.Handler.ServeHTTP(response, request)
 }
 
+// httpCompressEncoding returns the Content-Encoding
+// used by HTTPCompressHandler for request or an empty string
+func httpCompressEncoding(request *http.Request) string {
+	accept := request.Header.Get("Accept-Encoding")
+	switch {
+	case httpAcceptsEncoding(accept, "gzip"):
+		return "gzip"
+	case httpAcceptsEncoding(accept, "deflate"):
+		return "deflate"
+	}
+	return ""
+}
+
 // HTTPPostJSON marshalles data as JSON
 // and sends it as HTTP POST request to url.
 // If the response status code is not 200 OK,
@@ -142,83 +211,75 @@ func HTTPPutForm(url string, data url.Values) (statusCode int, statusText string
 
 // HTTPRespondMarshalJSON marshals response as JSON to responseWriter, sets Content-Type to application/json
 // and compresses the response if Content-Encoding from the request allows it.
-func HTTPRespondMarshalJSON(response any, responseWriter http.ResponseWriter, request *http.Request) (err error) {
-	NewHTTPCompressHandlerFromFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
-		var data []byte
-		if data, err = json.Marshal(response); err == nil {
-			responseWriter.Header().Set("Content-Type", "application/json")
-			_, err = responseWriter.Write(data)
-		}
-	}).ServeHTTP(responseWriter, request)
-	return err
+// Optional cache options enable ETag and conditional request handling, see HTTPCacheOptions.
+func HTTPRespondMarshalJSON(response any, responseWriter http.ResponseWriter, request *http.Request, cache ...HTTPCacheOptions) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return httpRespondData(responseWriter, request, "application/json", data, cache)
 }
 
 // HTTPRespondMarshalIndentJSON marshals response as JSON to responseWriter, sets Content-Type to application/json
 // and compresses the response if Content-Encoding from the request allows it.
-// The JSON will be marshalled indented according to json.MarshalIndent
-func HTTPRespondMarshalIndentJSON(response any, prefix, indent string, responseWriter http.ResponseWriter, request *http.Request) (err error) {
-	NewHTTPCompressHandlerFromFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
-		var data []byte
-		if data, err = json.MarshalIndent(response, prefix, indent); err == nil {
-			responseWriter.Header().Set("Content-Type", "application/json")
-			_, err = responseWriter.Write(data)
-		}
-	}).ServeHTTP(responseWriter, request)
-	return err
+// The JSON will be marshalled indented according to json.MarshalIndent.
+// Optional cache options enable ETag and conditional request handling, see HTTPCacheOptions.
+func HTTPRespondMarshalIndentJSON(response any, prefix, indent string, responseWriter http.ResponseWriter, request *http.Request, cache ...HTTPCacheOptions) error {
+	data, err := json.MarshalIndent(response, prefix, indent)
+	if err != nil {
+		return err
+	}
+	return httpRespondData(responseWriter, request, "application/json", data, cache)
 }
 
 // HTTPRespondMarshalXML marshals response as XML to responseWriter, sets Content-Type to application/xml
 // and compresses the response if Content-Encoding from the request allows it.
 // If rootElement is not empty, then an additional root element with this name will be wrapped around the content.
-func HTTPRespondMarshalXML(response any, rootElement string, responseWriter http.ResponseWriter, request *http.Request) (err error) {
-	NewHTTPCompressHandlerFromFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
-		var data []byte
-		if data, err = xml.Marshal(response); err == nil {
-			responseWriter.Header().Set("Content-Type", "application/xml")
-			if rootElement == "" {
-				_, err = fmt.Fprintf(responseWriter, "%s%s", xml.Header, data)
-			} else {
-				_, err = fmt.Fprintf(responseWriter, "%s<%s>%s</%s>", xml.Header, rootElement, data, rootElement)
-			}
-		}
-	}).ServeHTTP(responseWriter, request)
-	return err
+// Optional cache options enable ETag and conditional request handling, see HTTPCacheOptions.
+func HTTPRespondMarshalXML(response any, rootElement string, responseWriter http.ResponseWriter, request *http.Request, cache ...HTTPCacheOptions) error {
+	data, err := xml.Marshal(response)
+	if err != nil {
+		return err
+	}
+	if rootElement == "" {
+		data = fmt.Appendf(nil, "%s%s", xml.Header, data)
+	} else {
+		data = fmt.Appendf(nil, "%s<%s>%s</%s>", xml.Header, rootElement, data, rootElement)
+	}
+	return httpRespondData(responseWriter, request, "application/xml", data, cache)
 }
 
 // HTTPRespondMarshalIndentXML marshals response as XML to responseWriter, sets Content-Type to application/xml
 // and compresses the response if Content-Encoding from the request allows it.
 // The XML will be marshalled indented according to xml.MarshalIndent.
 // If rootElement is not empty, then an additional root element with this name will be wrapped around the content.
-func HTTPRespondMarshalIndentXML(response any, rootElement string, prefix, indent string, responseWriter http.ResponseWriter, request *http.Request) (err error) {
-	NewHTTPCompressHandlerFromFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
-		var data []byte
-		contentPrefix := prefix
-		if rootElement != "" {
-			contentPrefix += indent
-		}
-		if data, err = xml.MarshalIndent(response, contentPrefix, indent); err == nil {
-			responseWriter.Header().Set("Content-Type", "application/xml")
-			if rootElement == "" {
-				_, err = fmt.Fprintf(responseWriter, "%s%s\n%s", prefix, xml.Header, data)
-			} else {
-				_, err = fmt.Fprintf(responseWriter, "%s%s%s<%s>\n%s\n%s</%s>", prefix, xml.Header, prefix, rootElement, data, prefix, rootElement)
-			}
-		}
-	}).ServeHTTP(responseWriter, request)
-	return err
+// Optional cache options enable ETag and conditional request handling, see HTTPCacheOptions.
+func HTTPRespondMarshalIndentXML(response any, rootElement string, prefix, indent string, responseWriter http.ResponseWriter, request *http.Request, cache ...HTTPCacheOptions) error {
+	contentPrefix := prefix
+	if rootElement != "" {
+		contentPrefix += indent
+	}
+	data, err := xml.MarshalIndent(response, contentPrefix, indent)
+	if err != nil {
+		return err
+	}
+	if rootElement == "" {
+		data = fmt.Appendf(nil, "%s%s\n%s", prefix, xml.Header, data)
+	} else {
+		data = fmt.Appendf(nil, "%s%s%s<%s>\n%s\n%s</%s>", prefix, xml.Header, prefix, rootElement, data, prefix, rootElement)
+	}
+	return httpRespondData(responseWriter, request, "application/xml", data, cache)
 }
 
 // HTTPRespondText sets Content-Type to text/plain
 // and compresses the response if Content-Encoding from the request allows it.
-func HTTPRespondText(response string, responseWriter http.ResponseWriter, request *http.Request) (err error) {
-	NewHTTPCompressHandlerFromFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
-		responseWriter.Header().Set("Content-Type", "text/plain")
-		_, err = responseWriter.Write([]byte(response))
-	}).ServeHTTP(responseWriter, request)
-	return err
+// Optional cache options enable ETag and conditional request handling, see HTTPCacheOptions.
+func HTTPRespondText(response string, responseWriter http.ResponseWriter, request *http.Request, cache ...HTTPCacheOptions) error {
+	return httpRespondData(responseWriter, request, "text/plain", []byte(response), cache)
 }
 
 // HTTPUnmarshalRequestBodyJSON reads a http.Request body and unmarshals it as JSON to result.
+// See HTTPDecodeRequest for other content types, size limits and validation.
 func HTTPUnmarshalRequestBodyJSON(request *http.Request, result any) error {
 	defer request.Body.Close()
 	body, err := io.ReadAll(request.Body)
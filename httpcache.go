@@ -0,0 +1,131 @@
+package dry
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPCacheOptions can be passed to the HTTPRespond functions
+// to support conditional requests and caching by clients.
+type HTTPCacheOptions struct {
+	// ETag sends a strong ETag computed from a hash of the response body
+	// and responds with 304 Not Modified if it matches If-None-Match
+	ETag bool
+	// Version is sent as ETag instead of a hash of the body if not empty,
+	// so that a response can be compared before marshalling it
+	Version string
+	// LastModified is sent as Last-Modified header if not zero
+	// and responds with 304 Not Modified if it is not after If-Modified-Since
+	LastModified time.Time
+	// CacheControl is sent as Cache-Control header if not empty,
+	// like "no-cache" to make clients revalidate with every request
+	// or "max-age=60"
+	CacheControl string
+}
+
+// HTTPNotModified returns true if the request headers
+// If-None-Match or If-Modified-Since indicate that the client
+// already has the version of the response described by cache.
+// In that case 304 Not Modified is responded with the
+// ETag, Last-Modified and Cache-Control headers from cache.
+// Use it with a caller supplied HTTPCacheOptions.Version
+// to skip loading and marshalling a response:
+//
+//	cache := dry.HTTPCacheOptions{Version: strconv.Itoa(doc.Revision), CacheControl: "no-cache"}
+//	if dry.HTTPNotModified(w, r, cache) {
+//		return
+//	}
+//	dry.HTTPRespondMarshalJSON(loadDocument(), w, r, cache)
+func HTTPNotModified(responseWriter http.ResponseWriter, request *http.Request, cache HTTPCacheOptions) bool {
+	return httpRespondCached(responseWriter, request, &cache, nil)
+}
+
+// httpRespondData writes data as response with contentType,
+// compressed if Content-Encoding from the request allows it.
+// Only the first cache options are used.
+func httpRespondData(responseWriter http.ResponseWriter, request *http.Request, contentType string, data []byte, cache []HTTPCacheOptions) (err error) {
+	if len(cache) > 0 && httpRespondCached(responseWriter, request, &cache[0], data) {
+		return nil
+	}
+	NewHTTPCompressHandlerFromFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		responseWriter.Header().Set("Content-Type", contentType)
+		_, err = responseWriter.Write(data)
+	}).ServeHTTP(responseWriter, request)
+	return err
+}
+
+// httpRespondCached sets the headers for cache and responds
+// with 304 Not Modified and returns true if the request is conditional
+// and matches. If cache.ETag is true and no Version is set,
+// then the ETag is computed from body.
+func httpRespondCached(responseWriter http.ResponseWriter, request *http.Request, cache *HTTPCacheOptions, body []byte) bool {
+	header := responseWriter.Header()
+	if cache.CacheControl != "" {
+		header.Set("Cache-Control", cache.CacheControl)
+	}
+	if !cache.LastModified.IsZero() {
+		header.Set("Last-Modified", cache.LastModified.UTC().Format(http.TimeFormat))
+	}
+	var etag string
+	switch {
+	case cache.Version != "":
+		etag = strings.Trim(cache.Version, `"`)
+	case cache.ETag && body != nil:
+		etag = hex.EncodeToString(BytesHash(body, HashXXH64))
+	}
+	if etag != "" {
+		// Compressed responses are different representations
+		// that need a different strong ETag
+		if encoding := httpCompressEncoding(request); encoding != "" {
+			etag += "-" + encoding
+		}
+		etag = `"` + etag + `"`
+		header.Set("ETag", etag)
+		httpAddVary(header, "Accept-Encoding")
+	}
+
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return false
+	}
+	notModified := false
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		// If-Modified-Since is ignored if If-None-Match is present
+		notModified = etag != "" && httpETagMatches(ifNoneMatch, etag)
+	} else if ifModifiedSince := request.Header.Get("If-Modified-Since"); ifModifiedSince != "" && !cache.LastModified.IsZero() {
+		t, err := http.ParseTime(ifModifiedSince)
+		notModified = err == nil && !cache.LastModified.Truncate(time.Second).After(t)
+	}
+	if notModified {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		responseWriter.WriteHeader(http.StatusNotModified)
+	}
+	return notModified
+}
+
+// httpETagMatches returns if the If-None-Match header value
+// contains etag or "*" using the weak comparison of RFC 9110
+func httpETagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, value := range strings.Split(ifNoneMatch, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || strings.TrimPrefix(value, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// httpAddVary adds value to the Vary header if not already present
+func httpAddVary(header http.Header, value string) {
+	for _, vary := range header.Values("Vary") {
+		for _, v := range strings.Split(vary, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", value)
+}
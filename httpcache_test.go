@@ -0,0 +1,89 @@
+package dry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPCacheOptions(t *testing.T) {
+	data := map[string]int{"a": 1}
+	respond := func(cache HTTPCacheOptions, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		request := httptest.NewRequest("GET", "/", nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			request.Header.Set(header[i], header[i+1])
+		}
+		recorder := httptest.NewRecorder()
+		err := HTTPRespondMarshalJSON(data, recorder, request, cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return recorder
+	}
+
+	// ETag from body hash
+	cache := HTTPCacheOptions{ETag: true, CacheControl: "no-cache"}
+	response := respond(cache)
+	etag := response.Header().Get("ETag")
+	if response.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) || response.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("invalid response %d: %v", response.Code, response.Header())
+	}
+	if response.Body.String() != `{"a":1}` {
+		t.Errorf("invalid body %q", response.Body)
+	}
+	response = respond(cache, "If-None-Match", `"other", `+etag)
+	if response.Code != http.StatusNotModified || response.Body.Len() != 0 || response.Header().Get("ETag") != etag {
+		t.Errorf("expected 304 with ETag, got %d: %v", response.Code, response.Header())
+	}
+	if response = respond(cache, "If-None-Match", `"other"`); response.Code != http.StatusOK {
+		t.Errorf("expected 200 for different ETag, got %d", response.Code)
+	}
+
+	// Compressed representation has a different ETag
+	response = respond(cache, "Accept-Encoding", "gzip")
+	gzipETag := response.Header().Get("ETag")
+	if gzipETag != strings.TrimSuffix(etag, `"`)+`-gzip"` || response.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("invalid ETag %s for gzip encoding", gzipETag)
+	}
+	if response = respond(cache, "Accept-Encoding", "gzip", "If-None-Match", gzipETag); response.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for gzip ETag, got %d", response.Code)
+	}
+
+	// Caller supplied version
+	cache = HTTPCacheOptions{Version: "v42"}
+	if response = respond(cache); response.Header().Get("ETag") != `"v42"` {
+		t.Errorf("expected ETag \"v42\", got %s", response.Header().Get("ETag"))
+	}
+	if response = respond(cache, "If-None-Match", `W/"v42"`); response.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for weak comparison, got %d", response.Code)
+	}
+
+	// Last-Modified
+	modified := time.Date(2024, 1, 1, 12, 0, 0, 500, time.UTC)
+	cache = HTTPCacheOptions{LastModified: modified}
+	if response = respond(cache); response.Header().Get("Last-Modified") != "Mon, 01 Jan 2024 12:00:00 GMT" {
+		t.Errorf("invalid Last-Modified %q", response.Header().Get("Last-Modified"))
+	}
+	if response = respond(cache, "If-Modified-Since", "Mon, 01 Jan 2024 12:00:00 GMT"); response.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", response.Code)
+	}
+	if response = respond(cache, "If-Modified-Since", "Mon, 01 Jan 2024 11:59:59 GMT"); response.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", response.Code)
+	}
+
+	// HTTPNotModified before marshalling
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("If-None-Match", `"v1"`)
+	recorder := httptest.NewRecorder()
+	if !HTTPNotModified(recorder, request, HTTPCacheOptions{Version: "v1"}) || recorder.Code != http.StatusNotModified {
+		t.Errorf("expected HTTPNotModified to respond with 304, got %d", recorder.Code)
+	}
+	request = httptest.NewRequest("POST", "/", nil)
+	request.Header.Set("If-None-Match", `"v1"`)
+	if HTTPNotModified(httptest.NewRecorder(), request, HTTPCacheOptions{Version: "v1"}) {
+		t.Error("POST request must not be answered with 304")
+	}
+}
@@ -0,0 +1,218 @@
+package dry
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+HTTPDebugHandler serves runtime introspection of a running process.
+The endpoint is selected by the last element of the request URL path,
+so the handler can be mounted under any prefix:
+
+	/goroutines  stack traces of all goroutines as text
+	/locks       current DebugMutex and DebugRWMutex holders as JSON
+	/syncmaps    contents of the registered SyncMaps as JSON
+	/memory      runtime memory statistics as JSON
+	/buildinfo   build information of the binary as text
+
+Any other path returns an index of the endpoints.
+
+The endpoints expose goroutine stacks and SyncMap contents,
+so every request is denied unless Auth returns true for it.
+HTTPDebugAllowLoopback allows requests from loopback addresses,
+but don't use it behind a reverse proxy on the same host,
+because then every request comes from a loopback address.
+
+Usage example:
+
+	debugHandler := dry.NewHTTPDebugHandler(dry.HTTPDebugAllowLoopback)
+	debugHandler.RegisterSyncMap("sessions", sessions)
+	http.Handle("/debug/dry/", debugHandler)
+*/
+type HTTPDebugHandler struct {
+	// Auth is called for every request and must return true
+	// to allow it, else 403 Forbidden is returned.
+	// If Auth is nil, then all requests are denied.
+	Auth func(request *http.Request) bool
+	// ErrorLog logs errors of writing responses,
+	// if nil the standard logger of the log package is used
+	ErrorLog *log.Logger
+
+	mutex    sync.RWMutex
+	syncMaps map[string]*SyncMap
+}
+
+// NewHTTPDebugHandler returns a HTTPDebugHandler using auth as Auth function.
+func NewHTTPDebugHandler(auth func(request *http.Request) bool) *HTTPDebugHandler {
+	return &HTTPDebugHandler{Auth: auth}
+}
+
+// RegisterSyncMap makes the contents of syncMap available under name.
+// Passing a nil syncMap removes a registered name.
+func (h *HTTPDebugHandler) RegisterSyncMap(name string, syncMap *SyncMap) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if syncMap == nil {
+		delete(h.syncMaps, name)
+		return
+	}
+	if h.syncMaps == nil {
+		h.syncMaps = make(map[string]*SyncMap)
+	}
+	h.syncMaps[name] = syncMap
+}
+
+func (h *HTTPDebugHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if !h.authorized(request) {
+		http.Error(response, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	response.Header().Set("Cache-Control", "no-store")
+
+	var err error
+	switch path.Base(request.URL.Path) {
+	case "goroutines":
+		err = HTTPRespondText(httpDebugGoroutines(), response, request)
+	case "locks":
+		err = HTTPRespondMarshalIndentJSON(DebugLockHolders(), "", "  ", response, request)
+	case "syncmaps":
+		err = HTTPRespondMarshalIndentJSON(h.syncMapContents(), "", "  ", response, request)
+	case "memory":
+		err = HTTPRespondMarshalIndentJSON(httpDebugMemory(), "", "  ", response, request)
+	case "buildinfo":
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			http.Error(response, "no build info available", http.StatusNotFound)
+			return
+		}
+		err = HTTPRespondText(info.String(), response, request)
+	default:
+		err = HTTPRespondText("goroutines\nlocks\nsyncmaps\nmemory\nbuildinfo\n", response, request)
+	}
+	if err != nil {
+		// The response might already be partially written
+		if h.ErrorLog != nil {
+			h.ErrorLog.Printf("HTTPDebugHandler %s: %s", request.URL.Path, err)
+		} else {
+			log.Printf("HTTPDebugHandler %s: %s", request.URL.Path, err)
+		}
+	}
+}
+
+func (h *HTTPDebugHandler) authorized(request *http.Request) bool {
+	return h.Auth != nil && h.Auth(request)
+}
+
+// HTTPDebugAllowLoopback can be used as HTTPDebugHandler.Auth
+// to allow only requests with a loopback remote address.
+// Behind a reverse proxy on the same host every request
+// has a loopback remote address, so don't use it there.
+func HTTPDebugAllowLoopback(request *http.Request) bool {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (h *HTTPDebugHandler) syncMapContents() map[string]map[string]any {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	result := make(map[string]map[string]any, len(h.syncMaps))
+	for name, syncMap := range h.syncMaps {
+		result[name] = httpDebugSyncMapContents(syncMap, make(map[*SyncMap]bool))
+	}
+	return result
+}
+
+// httpDebugSyncMapContents returns the unwrapped contents of syncMap,
+// parents are the SyncMaps containing syncMap to stop at cycles.
+func httpDebugSyncMapContents(syncMap *SyncMap, parents map[*SyncMap]bool) map[string]any {
+	parents[syncMap] = true
+	defer delete(parents, syncMap)
+
+	contents := syncMap.Clone()
+	for key, value := range contents {
+		contents[key] = httpDebugSyncValue(value, parents)
+	}
+	return contents
+}
+
+// httpDebugSyncValue unwraps the synchronized value types of this package
+// because their unexported fields would be marshalled as empty JSON objects.
+func httpDebugSyncValue(value any, parents map[*SyncMap]bool) any {
+	switch v := value.(type) {
+	case *SyncBool:
+		return v.Get()
+	case *SyncInt:
+		return v.Get()
+	case *SyncFloat:
+		return v.Get()
+	case *SyncString:
+		return v.Get()
+	case *SyncMap:
+		if parents[v] {
+			return "<cyclic reference>"
+		}
+		return httpDebugSyncMapContents(v, parents)
+	case *SyncStringMap:
+		return v.Clone()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+// httpDebugGoroutines returns the stack traces of all goroutines,
+// growing the buffer until all of them fit.
+func httpDebugGoroutines() string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+func httpDebugMemory() map[string]any {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	var lastGC string
+	if m.LastGC > 0 {
+		lastGC = time.Unix(0, int64(m.LastGC)).Format(time.RFC3339) //#nosec G115
+	}
+	return map[string]any{
+		"Goroutines":   runtime.NumGoroutine(),
+		"Alloc":        StringFormatMemory(m.Alloc),
+		"TotalAlloc":   StringFormatMemory(m.TotalAlloc),
+		"Sys":          StringFormatMemory(m.Sys),
+		"HeapAlloc":    StringFormatMemory(m.HeapAlloc),
+		"HeapSys":      StringFormatMemory(m.HeapSys),
+		"HeapIdle":     StringFormatMemory(m.HeapIdle),
+		"HeapInuse":    StringFormatMemory(m.HeapInuse),
+		"HeapReleased": StringFormatMemory(m.HeapReleased),
+		"HeapObjects":  StringFormatBigInt(m.HeapObjects),
+		"StackInuse":   StringFormatMemory(m.StackInuse),
+		"StackSys":     StringFormatMemory(m.StackSys),
+		"Mallocs":      StringFormatBigInt(m.Mallocs),
+		"Frees":        StringFormatBigInt(m.Frees),
+		"NumGC":        m.NumGC,
+		"LastGC":       lastGC,
+		"PauseTotal":   time.Duration(m.PauseTotalNs).String(), //#nosec G115
+		"GCCPUPercent": strings.TrimSuffix(fmt.Sprintf("%.2f", m.GCCPUFraction*100), ".00") + "%",
+	}
+}
@@ -0,0 +1,63 @@
+package dry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPDebugHandler(t *testing.T) {
+	syncMap := NewSyncMap()
+	syncMap.AddInt("counter", 42)
+	syncMap.AddString("name", "test")
+	nested := NewSyncMap()
+	nested.Add("parent", syncMap)
+	syncMap.Add("nested", nested)
+
+	handler := NewHTTPDebugHandler(nil)
+	handler.RegisterSyncMap("test", syncMap)
+
+	request := httptest.NewRequest("GET", "/debug/syncmaps", nil)
+	request.RemoteAddr = "127.0.0.1:1234"
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d without Auth, got %d", http.StatusForbidden, response.Code)
+	}
+
+	handler.Auth = HTTPDebugAllowLoopback
+	request.RemoteAddr = "192.0.2.1:1234"
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d for non loopback address, got %d", http.StatusForbidden, response.Code)
+	}
+
+	request.RemoteAddr = "127.0.0.1:1234"
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, response.Code)
+	}
+	var result map[string]map[string]any
+	err := json.Unmarshal(response.Body.Bytes(), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["test"]["counter"] != float64(42) || result["test"]["name"] != "test" {
+		t.Fatalf("invalid syncmaps result: %#v", result)
+	}
+	if nested, _ := result["test"]["nested"].(map[string]any); nested["parent"] != "<cyclic reference>" {
+		t.Fatalf("invalid cyclic syncmaps result: %#v", result)
+	}
+
+	handler.Auth = func(*http.Request) bool { return true }
+	request = httptest.NewRequest("GET", "/debug/memory", nil)
+	request.RemoteAddr = "192.0.2.1:1234"
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, response.Code)
+	}
+}
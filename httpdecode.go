@@ -0,0 +1,368 @@
+package dry
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// HTTPDecodeOptions configures HTTPDecodeRequest.
+// A nil *HTTPDecodeOptions is valid and uses the defaults.
+type HTTPDecodeOptions struct {
+	// MaxBodySize in bytes, defaults to 10 MiB
+	MaxBodySize int64
+	// DisallowUnknownFields returns an error for JSON object keys
+	// and form fields that don't match a struct field.
+	// Not supported for XML.
+	DisallowUnknownFields bool
+}
+
+// HTTPFieldError describes a problem with a single field of a request.
+type HTTPFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e HTTPFieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// HTTPRequestError is returned by HTTPDecodeRequest.
+// It implements http.Handler to respond with its StatusCode
+// and itself as JSON body like:
+//
+//	{"error":"invalid request fields","fields":[{"field":"age","message":"invalid value \"x\""}]}
+type HTTPRequestError struct {
+	StatusCode int              `json:"-"`
+	Message    string           `json:"error"`
+	Fields     []HTTPFieldError `json:"fields,omitempty"`
+}
+
+func (e *HTTPRequestError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	fields := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = field.Error()
+	}
+	return e.Message + ": " + strings.Join(fields, ", ")
+}
+
+func (e *HTTPRequestError) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("X-Content-Type-Options", "nosniff")
+	response.WriteHeader(e.StatusCode)
+	json.NewEncoder(response).Encode(e) //#nosec G104
+}
+
+/*
+HTTPDecodeRequest decodes the body of request depending on its Content-Type
+as JSON, XML, application/x-www-form-urlencoded or multipart/form-data
+into a new T, then sets the struct fields tagged with `query:"name"`
+from the URL query and fields tagged with `path:"name"` from
+request.PathValue(name).
+
+Form fields are mapped to struct fields by a `form:"name"` tag,
+the name of a `json` tag, or the Go field name.
+Query, path and form values are parsed like environment variables by EnvBind,
+multiple values are set as slice elements.
+Files of multipart forms are set to fields of type
+*multipart.FileHeader or []*multipart.FileHeader.
+
+A struct T is checked with Validate after decoding,
+validation errors are returned with status 422 Unprocessable Entity
+and field paths like "items[2].name" that use the query, path
+and body format tag names of the fields like decoding errors.
+If *T implements interface{ Validate() error },
+then its Validate method is called afterwards.
+
+All errors are returned as *HTTPRequestError
+that can be used as http.Handler to respond with a JSON error:
+
+	type CreateUser struct {
+		OrgID string `path:"org"`
+		Name  string `json:"name"`
+		Age   int    `json:"age"`
+	}
+
+	func handleCreateUser(w http.ResponseWriter, r *http.Request) {
+		user, err := dry.HTTPDecodeRequest[CreateUser](r, nil)
+		if err != nil {
+			err.(*dry.HTTPRequestError).ServeHTTP(w, r)
+			return
+		}
+		...
+	}
+*/
+func HTTPDecodeRequest[T any](request *http.Request, opts *HTTPDecodeOptions) (result T, err error) {
+	if opts == nil {
+		opts = &HTTPDecodeOptions{}
+	}
+	maxBodySize := opts.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = 10 << 20
+	}
+	v := reflect.ValueOf(&result).Elem()
+
+	if request.Body != nil && request.Body != http.NoBody {
+		request.Body = http.MaxBytesReader(nil, request.Body, maxBodySize)
+		defer request.Body.Close()
+		err = httpDecodeBody(request, v, maxBodySize, opts)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if v.Kind() == reflect.Struct {
+		var fieldErrs []HTTPFieldError
+		query := request.URL.Query()
+		httpDecodeBindFields(v, "query", &fieldErrs, func(name string) []string { return query[name] })
+		httpDecodeBindFields(v, "path", &fieldErrs, func(name string) []string {
+			if value := request.PathValue(name); value != "" {
+				return []string{value}
+			}
+			return nil
+		})
+		if len(fieldErrs) > 0 {
+			return result, &HTTPRequestError{StatusCode: http.StatusBadRequest, Message: "invalid request parameters", Fields: fieldErrs}
+		}
+
+		if err = validate(&result, httpDecodeFieldNamer(request)); err != nil {
+			for _, err := range AsErrorList(err) {
+				var validationErr *ValidationError
+				if errors.As(err, &validationErr) {
+					fieldErrs = append(fieldErrs, HTTPFieldError{Field: validationErr.Field, Message: validationErr.Message})
+				} else {
+					fieldErrs = append(fieldErrs, HTTPFieldError{Message: err.Error()})
+				}
+			}
+			return result, &HTTPRequestError{StatusCode: http.StatusUnprocessableEntity, Message: "invalid request fields", Fields: fieldErrs}
+		}
+	}
+
+	if validator, ok := any(&result).(interface{ Validate() error }); ok {
+		err = validator.Validate()
+		if err != nil {
+			return result, &HTTPRequestError{StatusCode: http.StatusUnprocessableEntity, Message: err.Error()}
+		}
+	}
+	return result, nil
+}
+
+func httpDecodeBody(request *http.Request, v reflect.Value, maxBodySize int64, opts *HTTPDecodeOptions) error {
+	contentType := request.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && contentType != "" {
+		return &HTTPRequestError{StatusCode: http.StatusUnsupportedMediaType, Message: err.Error()}
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(request.Body)
+		if opts.DisallowUnknownFields {
+			decoder.DisallowUnknownFields()
+		}
+		err = decoder.Decode(v.Addr().Interface())
+		if err == nil && decoder.More() {
+			err = errors.New("unexpected data after JSON value")
+		}
+		return httpDecodeError(err)
+
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return httpDecodeError(xml.NewDecoder(request.Body).Decode(v.Addr().Interface()))
+
+	case mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data":
+		if v.Kind() != reflect.Struct {
+			return &HTTPRequestError{StatusCode: http.StatusUnsupportedMediaType, Message: fmt.Sprintf("can't decode form into %s", v.Type())}
+		}
+		if mediaType == "multipart/form-data" {
+			err = request.ParseMultipartForm(maxBodySize)
+		} else {
+			err = request.ParseForm()
+		}
+		if err != nil {
+			return httpDecodeError(err)
+		}
+		var files map[string][]*multipart.FileHeader
+		if request.MultipartForm != nil {
+			files = request.MultipartForm.File
+		}
+		var fieldErrs []HTTPFieldError
+		bound := httpDecodeBindFields(v, "form", &fieldErrs, func(name string) []string { return request.PostForm[name] })
+		httpDecodeBindFiles(v, files, bound)
+		if opts.DisallowUnknownFields {
+			for name := range request.PostForm {
+				if !bound[name] {
+					fieldErrs = append(fieldErrs, HTTPFieldError{Field: name, Message: "unknown field"})
+				}
+			}
+			for name := range files {
+				if !bound[name] {
+					fieldErrs = append(fieldErrs, HTTPFieldError{Field: name, Message: "unknown field"})
+				}
+			}
+		}
+		if len(fieldErrs) > 0 {
+			return &HTTPRequestError{StatusCode: http.StatusBadRequest, Message: "invalid form fields", Fields: fieldErrs}
+		}
+		return nil
+
+	case contentType == "" && request.ContentLength == 0:
+		return nil
+
+	default:
+		return &HTTPRequestError{StatusCode: http.StatusUnsupportedMediaType, Message: fmt.Sprintf("unsupported Content-Type %q", contentType)}
+	}
+}
+
+// httpDecodeError converts errors of decoding a request body to *HTTPRequestError
+func httpDecodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var (
+		maxBytesErr   *http.MaxBytesError
+		jsonTypeErr   *json.UnmarshalTypeError
+		jsonSyntaxErr *json.SyntaxError
+	)
+	unknownField, isUnknownField := httpJSONUnknownField(err)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &HTTPRequestError{StatusCode: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("request body larger than %d bytes", maxBytesErr.Limit)}
+	case errors.As(err, &jsonTypeErr) && jsonTypeErr.Field != "":
+		return &HTTPRequestError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "invalid request fields",
+			Fields:     []HTTPFieldError{{Field: jsonTypeErr.Field, Message: fmt.Sprintf("can't use JSON %s as %s", jsonTypeErr.Value, jsonTypeErr.Type)}},
+		}
+	case errors.As(err, &jsonSyntaxErr):
+		return &HTTPRequestError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("invalid JSON at offset %d: %s", jsonSyntaxErr.Offset, err)}
+	case isUnknownField:
+		return &HTTPRequestError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "invalid request fields",
+			Fields:     []HTTPFieldError{{Field: unknownField, Message: "unknown field"}},
+		}
+	case errors.Is(err, io.EOF):
+		return &HTTPRequestError{StatusCode: http.StatusBadRequest, Message: "empty request body"}
+	default:
+		return &HTTPRequestError{StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+}
+
+// httpJSONUnknownField returns the field name of an error
+// from json.Decoder.DisallowUnknownFields, which has no error type
+func httpJSONUnknownField(err error) (name string, ok bool) {
+	quoted, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return "", false
+	}
+	_, err = fmt.Sscanf(quoted, "%q", &name)
+	return name, err == nil
+}
+
+// httpDecodeFieldNamer returns a function that names struct fields
+// by their query or path tag, or the tag of the body format of request
+func httpDecodeFieldNamer(request *http.Request) func(reflect.StructField) string {
+	tagNames := []string{"query", "path", "json"}
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		tagNames = []string{"query", "path", "xml"}
+	case mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data":
+		tagNames = []string{"query", "path", "form", "json"}
+	}
+	return func(structField reflect.StructField) string {
+		for _, tagName := range tagNames {
+			name, _, _ := strings.Cut(structField.Tag.Get(tagName), ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return structField.Name
+	}
+}
+
+// httpDecodeBindFields sets the fields of the struct v
+// that have a name for tagName from the values returned by lookup.
+// For tagName "form" untagged fields are named by their json tag or Go name.
+// The names of the fields that are set are returned.
+func httpDecodeBindFields(v reflect.Value, tagName string, fieldErrs *[]HTTPFieldError, lookup func(name string) []string) map[string]bool {
+	bound := make(map[string]bool)
+	httpDecodeWalkFields(v, tagName, func(name string, field reflect.Value, tag reflect.StructTag) {
+		if field.Type() == reflectTypeOfFileHeaderPtr || field.Type() == reflect.SliceOf(reflectTypeOfFileHeaderPtr) {
+			return
+		}
+		values := lookup(name)
+		if len(values) == 0 {
+			return
+		}
+		bound[name] = true
+		err := configSetValue(field, values, tag.Get("sep"))
+		if err != nil {
+			*fieldErrs = append(*fieldErrs, HTTPFieldError{Field: name, Message: fmt.Sprintf("invalid value %q", strings.Join(values, ","))})
+		}
+	})
+	return bound
+}
+
+var reflectTypeOfFileHeaderPtr = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// httpDecodeBindFiles sets the *multipart.FileHeader and
+// []*multipart.FileHeader form fields of the struct v from files
+func httpDecodeBindFiles(v reflect.Value, files map[string][]*multipart.FileHeader, bound map[string]bool) {
+	httpDecodeWalkFields(v, "form", func(name string, field reflect.Value, tag reflect.StructTag) {
+		headers := files[name]
+		if len(headers) == 0 {
+			return
+		}
+		switch field.Type() {
+		case reflectTypeOfFileHeaderPtr:
+			field.Set(reflect.ValueOf(headers[0]))
+			bound[name] = true
+		case reflect.SliceOf(reflectTypeOfFileHeaderPtr):
+			field.Set(reflect.ValueOf(headers))
+			bound[name] = true
+		}
+	})
+}
+
+// httpDecodeWalkFields calls fn for every exported field of the struct v
+// with a name for tagName, embedded structs are inlined
+func httpDecodeWalkFields(v reflect.Value, tagName string, fn func(name string, field reflect.Value, tag reflect.StructTag)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !ReflectStructFieldIsExported(structField) {
+			continue
+		}
+		name, _, _ := strings.Cut(structField.Tag.Get(tagName), ",")
+		if name == "-" {
+			continue
+		}
+		if structField.Anonymous && name == "" && structField.Type.Kind() == reflect.Struct {
+			httpDecodeWalkFields(v.Field(i), tagName, fn)
+			continue
+		}
+		if name == "" && tagName == "form" {
+			name, _, _ = strings.Cut(structField.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = structField.Name
+			}
+		}
+		if name != "" {
+			fn(name, v.Field(i), structField.Tag)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package dry
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type httpDecodeTestUser struct {
+	OrgID  string                `path:"org" json:"-" form:"-"`
+	Notify bool                  `query:"notify" json:"-" form:"-"`
+	Tags   []string              `query:"tag" json:"-" form:"-"`
+	Name   string                `json:"name" xml:"name" validate:"max=10"`
+	Age    int                   `json:"age" xml:"age"`
+	Avatar *multipart.FileHeader `json:"-" form:"avatar"`
+}
+
+func (u *httpDecodeTestUser) Validate() error {
+	if u.Age < 0 {
+		return errors.New("age must not be negative")
+	}
+	return nil
+}
+
+func TestHTTPDecodeRequest(t *testing.T) {
+	var (
+		decoded httpDecodeTestUser
+		err     error
+		opts    *HTTPDecodeOptions
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orgs/{org}/users", func(w http.ResponseWriter, r *http.Request) {
+		decoded, err = HTTPDecodeRequest[httpDecodeTestUser](r, opts)
+	})
+	decode := func(contentType, body string) *HTTPRequestError {
+		t.Helper()
+		request := httptest.NewRequest("POST", "/orgs/acme/users?notify=true&tag=a&tag=b", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		decoded, err = httpDecodeTestUser{}, nil
+		mux.ServeHTTP(httptest.NewRecorder(), request)
+		if err == nil {
+			return nil
+		}
+		var requestErr *HTTPRequestError
+		if !errors.As(err, &requestErr) {
+			t.Fatalf("expected *HTTPRequestError, got %T: %s", err, err)
+		}
+		return requestErr
+	}
+	expected := httpDecodeTestUser{OrgID: "acme", Notify: true, Tags: []string{"a", "b"}, Name: "Alice", Age: 30}
+
+	for _, test := range []struct{ contentType, body string }{
+		{"application/json", `{"name":"Alice","age":30}`},
+		{"application/json; charset=utf-8", `{"name":"Alice","age":30,"unknown":1}`},
+		{"application/xml", `<user><name>Alice</name><age>30</age></user>`},
+		{"application/x-www-form-urlencoded", url.Values{"name": {"Alice"}, "age": {"30"}}.Encode()},
+	} {
+		if requestErr := decode(test.contentType, test.body); requestErr != nil {
+			t.Errorf("%s: %s", test.contentType, requestErr)
+			continue
+		}
+		if decoded.OrgID != expected.OrgID || decoded.Notify != expected.Notify ||
+			strings.Join(decoded.Tags, ",") != "a,b" || decoded.Name != expected.Name || decoded.Age != expected.Age {
+			t.Errorf("%s: decoded %+v", test.contentType, decoded)
+		}
+	}
+
+	tests := []struct {
+		contentType string
+		body        string
+		statusCode  int
+		field       string
+	}{
+		{"application/json", `{"name":"Alice","age":"x"}`, http.StatusBadRequest, "age"},
+		{"application/json", `{"name":`, http.StatusBadRequest, ""},
+		{"application/json", `{"name":"Alice"} {}`, http.StatusBadRequest, ""},
+		{"application/json", `{"name":"Alice","age":-1}`, http.StatusUnprocessableEntity, ""},
+		{"application/json", `{"name":"Alice Anderson"}`, http.StatusUnprocessableEntity, "name"},
+		{"application/x-www-form-urlencoded", "age=x", http.StatusBadRequest, "age"},
+		{"application/x-www-form-urlencoded", "name=Alice+Anderson", http.StatusUnprocessableEntity, "name"},
+		{"text/plain", "Alice", http.StatusUnsupportedMediaType, ""},
+		{"application/json", `{"name":"` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge, ""},
+	}
+	opts = &HTTPDecodeOptions{MaxBodySize: 64}
+	for _, test := range tests {
+		requestErr := decode(test.contentType, test.body)
+		if requestErr == nil {
+			t.Errorf("%s %s: expected error", test.contentType, test.body)
+			continue
+		}
+		if requestErr.StatusCode != test.statusCode {
+			t.Errorf("%s %s: status %d, expected %d: %s", test.contentType, test.body, requestErr.StatusCode, test.statusCode, requestErr)
+		}
+		if test.field != "" && (len(requestErr.Fields) != 1 || requestErr.Fields[0].Field != test.field) {
+			t.Errorf("%s %s: expected error for field %s, got %v", test.contentType, test.body, test.field, requestErr.Fields)
+		}
+	}
+
+	opts = &HTTPDecodeOptions{DisallowUnknownFields: true}
+	requestErr := decode("application/json", `{"name":"Alice","unknown":1}`)
+	if requestErr == nil || len(requestErr.Fields) != 1 || requestErr.Fields[0].Field != "unknown" {
+		t.Errorf("expected unknown field error, got %v", requestErr)
+	}
+	requestErr = decode("application/x-www-form-urlencoded", "name=Alice&unknown=1")
+	if requestErr == nil || len(requestErr.Fields) != 1 || requestErr.Fields[0].Field != "unknown" {
+		t.Errorf("expected unknown form field error, got %v", requestErr)
+	}
+
+	// Multipart form with file
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("name", "Alice")
+	part, _ := writer.CreateFormFile("avatar", "avatar.png")
+	part.Write([]byte("png"))
+	writer.Close()
+	if requestErr = decode(writer.FormDataContentType(), body.String()); requestErr != nil {
+		t.Fatal(requestErr)
+	}
+	if decoded.Name != "Alice" || decoded.Avatar == nil || decoded.Avatar.Filename != "avatar.png" {
+		t.Errorf("multipart: decoded %+v", decoded)
+	}
+
+	// Rendering as JSON
+	recorder := httptest.NewRecorder()
+	(&HTTPRequestError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "invalid request fields",
+		Fields:     []HTTPFieldError{{Field: "age", Message: "invalid"}},
+	}).ServeHTTP(recorder, nil)
+	data, _ := io.ReadAll(recorder.Body)
+	if recorder.Code != http.StatusBadRequest || string(data) != `{"error":"invalid request fields","fields":[{"field":"age","message":"invalid"}]}`+"\n" {
+		t.Errorf("invalid JSON error response %d: %s", recorder.Code, data)
+	}
+}
@@ -0,0 +1,339 @@
+package dry
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPFileServerOptions configures NewHTTPFileServer.
+// A nil *HTTPFileServerOptions is valid and uses the defaults.
+type HTTPFileServerOptions struct {
+	// IndexFile is served for directory paths, defaults to "index.html"
+	IndexFile string
+	// MaxAge for the Cache-Control header of files that are not fingerprinted,
+	// zero sends "no-cache" so that clients revalidate with the ETag
+	MaxAge time.Duration
+	// Fingerprinted returns true if a filename contains a content hash
+	// and can be cached forever with "Cache-Control: immutable",
+	// for example HTTPFileIsFingerprinted.
+	// If nil, then no file is treated as fingerprinted
+	Fingerprinted func(name string) bool
+	// CompressCacheSize is the maximum number of bytes of gzip compressed
+	// files without .gz sidecar that are cached in memory,
+	// zero disables on-the-fly compression
+	CompressCacheSize int64
+	// CompressMaxFileSize limits on-the-fly compression to smaller files,
+	// defaults to 1 MiB
+	CompressMaxFileSize int64
+}
+
+/*
+HTTPFileServer is a http.Handler that serves static files from a fs.FS.
+
+For every file it sends an ETag and Last-Modified header
+and supports conditional and Range requests via http.ServeContent.
+If the request accepts br or gzip encoding and a precompressed sidecar file
+with the extension ".br" or ".gz" exists next to the requested file,
+then the sidecar file is served with the matching Content-Encoding.
+Compressible files without a sidecar are gzip compressed on the fly
+and cached in memory up to HTTPFileServerOptions.CompressCacheSize bytes,
+evicting the least recently served files first.
+Filenames for which HTTPFileServerOptions.Fingerprinted returns true
+are sent with "Cache-Control: public, max-age=31536000, immutable".
+
+Usage example:
+
+	fileServer := dry.NewHTTPFileServerDir("./public", &dry.HTTPFileServerOptions{
+		CompressCacheSize: 16 << 20,
+	})
+	http.Handle("/static/", http.StripPrefix("/static", fileServer))
+*/
+type HTTPFileServer struct {
+	fsys fs.FS
+	opts HTTPFileServerOptions
+
+	cacheMutex sync.Mutex
+	cacheSize  int64
+	cacheList  *list.List // of *httpFileCacheEntry, most recently used first
+	cacheMap   map[string]*list.Element
+}
+
+type httpFileCacheEntry struct {
+	name    string
+	modTime time.Time
+	size    int64
+	data    []byte
+}
+
+// NewHTTPFileServer returns a HTTPFileServer for fsys.
+func NewHTTPFileServer(fsys fs.FS, opts *HTTPFileServerOptions) *HTTPFileServer {
+	s := &HTTPFileServer{
+		fsys:      fsys,
+		cacheList: list.New(),
+		cacheMap:  make(map[string]*list.Element),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.IndexFile == "" {
+		s.opts.IndexFile = "index.html"
+	}
+	if s.opts.CompressMaxFileSize == 0 {
+		s.opts.CompressMaxFileSize = 1 << 20
+	}
+	return s
+}
+
+// NewHTTPFileServerDir returns a HTTPFileServer for the directory dir.
+func NewHTTPFileServerDir(dir string, opts *HTTPFileServerOptions) *HTTPFileServer {
+	return NewHTTPFileServer(os.DirFS(dir), opts)
+}
+
+// httpFileSidecars in order of preference
+var httpFileSidecars = []struct{ encoding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+var httpFingerprintRegexp = regexp.MustCompile(`[.\-_]([0-9a-fA-F]{8,})\.[^./]+$`)
+
+// HTTPFileIsFingerprinted returns true if the base name of name
+// contains a hex hash of at least 8 characters before the extension,
+// separated by a dot, dash or underscore like "app.3f2a9c1b.js" or "app-3f2a9c1b.css".
+// The hash must contain a digit and a letter from a to f,
+// so dates or versions like "app-20240101.js" don't match.
+func HTTPFileIsFingerprinted(name string) bool {
+	match := httpFingerprintRegexp.FindStringSubmatch(path.Base(name))
+	return match != nil &&
+		strings.ContainsAny(match[1], "0123456789") &&
+		strings.ContainsAny(match[1], "abcdefABCDEF")
+}
+
+func (s *HTTPFileServer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		response.Header().Set("Allow", "GET, HEAD")
+		http.Error(response, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+request.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, s.opts.IndexFile)
+		info, err = fs.Stat(s.fsys, name)
+	}
+	if err != nil || info.IsDir() {
+		httpFileServerError(response, err)
+		return
+	}
+
+	header := response.Header()
+	header.Set("Vary", "Accept-Encoding")
+	switch {
+	case s.opts.Fingerprinted != nil && s.opts.Fingerprinted(name):
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	case s.opts.MaxAge > 0:
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int64(s.opts.MaxAge/time.Second)))
+	default:
+		header.Set("Cache-Control", "no-cache")
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	etag := fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
+
+	acceptEncoding := request.Header.Get("Accept-Encoding")
+	for _, sidecar := range httpFileSidecars {
+		if !httpAcceptsEncoding(acceptEncoding, sidecar.encoding) {
+			continue
+		}
+		sidecarInfo, err := fs.Stat(s.fsys, name+sidecar.ext)
+		if err != nil || sidecarInfo.IsDir() {
+			continue
+		}
+		file, err := s.fsys.Open(name + sidecar.ext)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+		content, err := httpFileReadSeeker(file)
+		if err != nil {
+			httpFileServerError(response, err)
+			return
+		}
+		s.serveEncoded(response, request, name, contentType, etag, sidecar.encoding, info.ModTime(), content)
+		return
+	}
+
+	if s.opts.CompressCacheSize > 0 &&
+		info.Size() <= s.opts.CompressMaxFileSize &&
+		httpIsCompressibleContentType(contentType) &&
+		httpAcceptsEncoding(acceptEncoding, "gzip") {
+		data, err := s.compressed(name, info)
+		if err != nil {
+			httpFileServerError(response, err)
+			return
+		}
+		s.serveEncoded(response, request, name, contentType, etag, "gzip", info.ModTime(), bytes.NewReader(data))
+		return
+	}
+
+	file, err := s.fsys.Open(name)
+	if err != nil {
+		httpFileServerError(response, err)
+		return
+	}
+	defer file.Close()
+	content, err := httpFileReadSeeker(file)
+	if err != nil {
+		httpFileServerError(response, err)
+		return
+	}
+	header.Set("ETag", `"`+etag+`"`)
+	http.ServeContent(response, request, name, info.ModTime(), content)
+}
+
+func (s *HTTPFileServer) serveEncoded(response http.ResponseWriter, request *http.Request, name, contentType, etag, encoding string, modTime time.Time, content io.ReadSeeker) {
+	header := response.Header()
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// Set Content-Type so that http.ServeContent
+	// does not sniff it from the compressed content
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Encoding", encoding)
+	header.Set("ETag", `"`+etag+"-"+encoding+`"`)
+	http.ServeContent(response, request, name, modTime, content)
+}
+
+// compressed returns the gzip compressed content of the file name
+// from the cache or compresses and caches it
+func (s *HTTPFileServer) compressed(name string, info fs.FileInfo) ([]byte, error) {
+	s.cacheMutex.Lock()
+	if elem, ok := s.cacheMap[name]; ok {
+		entry := elem.Value.(*httpFileCacheEntry)
+		if entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+			s.cacheList.MoveToFront(elem)
+			s.cacheMutex.Unlock()
+			return entry.data, nil
+		}
+		s.cacheRemove(elem)
+	}
+	s.cacheMutex.Unlock()
+
+	data, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writer := Gzip.GetWriter(&buf)
+	_, err = writer.Write(data)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	Gzip.ReturnWriter(writer)
+	if err != nil {
+		return nil, err
+	}
+	compressed := buf.Bytes()
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+	if int64(len(compressed)) > s.opts.CompressCacheSize {
+		return compressed, nil
+	}
+	if elem, ok := s.cacheMap[name]; ok {
+		// Compressed concurrently by another request
+		s.cacheRemove(elem)
+	}
+	for s.cacheSize+int64(len(compressed)) > s.opts.CompressCacheSize {
+		s.cacheRemove(s.cacheList.Back())
+	}
+	s.cacheMap[name] = s.cacheList.PushFront(&httpFileCacheEntry{
+		name:    name,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		data:    compressed,
+	})
+	s.cacheSize += int64(len(compressed))
+	return compressed, nil
+}
+
+// cacheRemove must be called with locked cacheMutex
+func (s *HTTPFileServer) cacheRemove(elem *list.Element) {
+	entry := s.cacheList.Remove(elem).(*httpFileCacheEntry)
+	delete(s.cacheMap, entry.name)
+	s.cacheSize -= int64(len(entry.data))
+}
+
+func httpFileServerError(response http.ResponseWriter, err error) {
+	switch {
+	case err == nil, errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrInvalid):
+		http.Error(response, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(response, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	default:
+		http.Error(response, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// httpFileReadSeeker returns file as io.ReadSeeker
+// or reads it into memory if it does not implement io.Seeker
+func httpFileReadSeeker(file fs.File) (io.ReadSeeker, error) {
+	if readSeeker, ok := file.(io.ReadSeeker); ok {
+		return readSeeker, nil
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// httpAcceptsEncoding returns if the Accept-Encoding header value
+// acceptEncoding contains encoding or "*" without a q-value of zero
+func httpAcceptsEncoding(acceptEncoding, encoding string) bool {
+	wildcardQ := 0.0
+	for _, value := range httpParseQualityValues(acceptEncoding) {
+		if strings.EqualFold(value.value, encoding) {
+			// An explicit value takes precedence over "*"
+			return value.q > 0
+		}
+		if value.value == "*" {
+			wildcardQ = value.q
+		}
+	}
+	return wildcardQ > 0
+}
+
+func httpIsCompressibleContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/javascript",
+		"application/json",
+		"application/xml",
+		"application/wasm",
+		"image/svg+xml":
+		return true
+	}
+	return false
+}
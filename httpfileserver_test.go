@@ -0,0 +1,185 @@
+package dry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestHTTPFileServer(t *testing.T) {
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	style := strings.Repeat("body { color: red; }\n", 100)
+	fsys := fstest.MapFS{
+		"index.html":         {Data: []byte("<html></html>"), ModTime: modTime},
+		"style.css":          {Data: []byte(style), ModTime: modTime},
+		"app.js":             {Data: []byte("console.log('plain')"), ModTime: modTime},
+		"app.js.br":          {Data: []byte("brotli"), ModTime: modTime},
+		"app.3f2a9c1b.js":    {Data: []byte("console.log('hashed')"), ModTime: modTime},
+		"data.bin":           {Data: []byte("0123456789"), ModTime: modTime},
+		"sub/index.html":     {Data: []byte("sub"), ModTime: modTime},
+		"sub/other/file.txt": {Data: []byte("file"), ModTime: modTime},
+	}
+	server := NewHTTPFileServer(fsys, &HTTPFileServerOptions{CompressCacheSize: 1 << 20, Fingerprinted: HTTPFileIsFingerprinted})
+
+	serve := func(method, path string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		request := httptest.NewRequest(method, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			request.Header.Set(header[i], header[i+1])
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	response := serve("GET", "/")
+	if response.Code != http.StatusOK || response.Body.String() != "<html></html>" {
+		t.Errorf("index: %d %q", response.Code, response.Body)
+	}
+	if response.Header().Get("Cache-Control") != "no-cache" || response.Header().Get("Last-Modified") == "" {
+		t.Errorf("invalid headers: %v", response.Header())
+	}
+	if response = serve("GET", "/sub/"); response.Body.String() != "sub" {
+		t.Errorf("sub index: %d %q", response.Code, response.Body)
+	}
+	if response = serve("GET", "/sub/other/"); response.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for directory without index, got %d", response.Code)
+	}
+	if response = serve("GET", "/missing.txt"); response.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", response.Code)
+	}
+	if response = serve("POST", "/index.html"); response.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", response.Code)
+	}
+
+	// Conditional request
+	response = serve("GET", "/data.bin")
+	etag := response.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+	if response = serve("GET", "/data.bin", "If-None-Match", etag); response.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", response.Code)
+	}
+
+	// Range request
+	response = serve("GET", "/data.bin", "Range", "bytes=2-4")
+	if response.Code != http.StatusPartialContent || response.Body.String() != "234" {
+		t.Errorf("range: %d %q", response.Code, response.Body)
+	}
+
+	// Precompressed sidecar
+	response = serve("GET", "/app.js", "Accept-Encoding", "gzip, br")
+	if response.Header().Get("Content-Encoding") != "br" || response.Body.String() != "brotli" {
+		t.Errorf("sidecar: %v %q", response.Header(), response.Body)
+	}
+	if !strings.HasPrefix(response.Header().Get("Content-Type"), "text/javascript") {
+		t.Errorf("invalid Content-Type %q", response.Header().Get("Content-Type"))
+	}
+	if response = serve("GET", "/app.js", "Accept-Encoding", "gzip, br;q=0"); response.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("expected gzip encoding, got %v", response.Header())
+	}
+
+	// On-the-fly compression
+	for i := 0; i < 2; i++ {
+		response = serve("GET", "/style.css", "Accept-Encoding", "gzip")
+		if response.Header().Get("Content-Encoding") != "gzip" || !strings.HasSuffix(response.Header().Get("ETag"), `-gzip"`) {
+			t.Fatalf("compression: %v", response.Header())
+		}
+		if data := BytesUnGzip(response.Body.Bytes()); string(data) != style {
+			t.Errorf("invalid decompressed content: %q", data)
+		}
+	}
+	if len(server.cacheMap) != 2 || server.cacheSize <= 0 {
+		t.Errorf("expected 2 cached files, got %d with %d bytes", len(server.cacheMap), server.cacheSize)
+	}
+	if response = serve("GET", "/data.bin", "Accept-Encoding", "gzip"); response.Header().Get("Content-Encoding") != "" {
+		t.Errorf("binary file must not be compressed: %v", response.Header())
+	}
+
+	// Fingerprinted
+	response = serve("GET", "/app.3f2a9c1b.js")
+	if response.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Errorf("invalid Cache-Control for fingerprinted file: %q", response.Header().Get("Cache-Control"))
+	}
+	server = NewHTTPFileServer(fsys, nil)
+	if response = serve("GET", "/app.3f2a9c1b.js"); response.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("file fingerprinted without Fingerprinted option: %q", response.Header().Get("Cache-Control"))
+	}
+}
+
+func TestHTTPFileIsFingerprinted(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"app.3f2a9c1b.js":         true,
+		"static/app-3F2A9C1B.css": true,
+		"app_0123456789abcdef.js": true,
+		"app.js":                  false,
+		"app-20240101.js":         false,
+		"report_12345678.csv":     false,
+		"app.deadbeef.js":         false,
+		"app.3f2a9c1.js":          false,
+	} {
+		if HTTPFileIsFingerprinted(name) != expected {
+			t.Errorf("HTTPFileIsFingerprinted(%q) != %t", name, expected)
+		}
+	}
+}
+
+func TestHTTPFileServerCompressCache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte(strings.Repeat("a", 1000))},
+		"b.txt": {Data: []byte(strings.Repeat("b", 1000))},
+		"c.txt": {Data: []byte(strings.Repeat("c", 1000))},
+	}
+	server := NewHTTPFileServer(fsys, &HTTPFileServerOptions{CompressCacheSize: 1})
+	compressed := func(name string) {
+		t.Helper()
+		info, _ := fsys.Stat(name)
+		if _, err := server.compressed(name, info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	compressed("a.txt")
+	if len(server.cacheMap) != 0 {
+		t.Errorf("file larger than cache was cached")
+	}
+
+	// All files compress to the same size
+	server = NewHTTPFileServer(fsys, &HTTPFileServerOptions{CompressCacheSize: 1 << 20})
+	compressed("a.txt")
+	size := server.cacheSize
+	server = NewHTTPFileServer(fsys, &HTTPFileServerOptions{CompressCacheSize: 2 * size})
+	compressed("a.txt")
+	compressed("b.txt")
+	compressed("a.txt")
+	compressed("c.txt")
+	if _, ok := server.cacheMap["b.txt"]; ok || len(server.cacheMap) != 2 || server.cacheSize != 2*size {
+		t.Errorf("expected least recently used b.txt to be evicted, cached: %v", server.cacheMap)
+	}
+}
+
+func Test_httpAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+		expected       bool
+	}{
+		{"", "gzip", false},
+		{"gzip", "gzip", true},
+		{"deflate, GZIP", "gzip", true},
+		{"gzip;q=0", "gzip", false},
+		{"gzip; q=0.5, br", "gzip", true},
+		{"*", "br", true},
+		{"*, br;q=0", "br", false},
+		{"*;q=0, gzip", "gzip", true},
+		{"gzipx", "gzip", false},
+	}
+	for _, test := range tests {
+		if result := httpAcceptsEncoding(test.acceptEncoding, test.encoding); result != test.expected {
+			t.Errorf("httpAcceptsEncoding(%q, %q) = %t", test.acceptEncoding, test.encoding, result)
+		}
+	}
+}
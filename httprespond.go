@@ -0,0 +1,285 @@
+package dry
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrHTTPNotAcceptable is returned by HTTPRespond if no supported
+// content type is accepted by the request.
+var ErrHTTPNotAcceptable = errors.New("no acceptable content type")
+
+// HTTPEncoderFunc encodes value to writer for a registered media type.
+type HTTPEncoderFunc func(writer io.Writer, value any) error
+
+type httpEncoder struct {
+	mediaType   string
+	contentType string
+	canEncode   func(value any) bool
+	encode      HTTPEncoderFunc
+}
+
+var (
+	httpEncodersMutex sync.RWMutex
+	// httpEncoders in order of preference
+	httpEncoders = []httpEncoder{
+		{"application/json", "application/json", nil, httpEncodeJSON},
+		{"application/xml", "application/xml", httpCanEncodeXML, httpEncodeXML},
+		{"text/csv", "text/csv; charset=utf-8", httpCanEncodeCSV, httpEncodeCSV},
+		{"text/plain", "text/plain; charset=utf-8", nil, httpEncodeText},
+	}
+)
+
+// HTTPRegisterEncoder registers an encoder for mediaType that
+// HTTPRespond uses if the Accept header of a request prefers it.
+// If canEncode is passed, then the encoder is only negotiated
+// for values for which canEncode returns true.
+// Registering an already registered media type including the built-in
+// "application/json", "application/xml", "text/csv" and "text/plain"
+// replaces its encoder, registering a nil encoder removes it.
+func HTTPRegisterEncoder(mediaType string, encoder HTTPEncoderFunc, canEncode ...func(value any) bool) {
+	httpEncodersMutex.Lock()
+	defer httpEncodersMutex.Unlock()
+
+	mediaType = strings.ToLower(mediaType)
+	registered := httpEncoder{mediaType, mediaType, nil, encoder}
+	if len(canEncode) > 0 {
+		registered.canEncode = canEncode[0]
+	}
+	for i := range httpEncoders {
+		if httpEncoders[i].mediaType == mediaType {
+			if encoder == nil {
+				httpEncoders = append(httpEncoders[:i:i], httpEncoders[i+1:]...)
+			} else {
+				httpEncoders[i] = registered
+			}
+			return
+		}
+	}
+	if encoder != nil {
+		httpEncoders = append(httpEncoders, registered)
+	}
+}
+
+/*
+HTTPRespond encodes value to responseWriter in the format
+that is preferred by the Accept header of the request
+and compresses the response if Content-Encoding from the request allows it.
+
+The built-in formats in order of preference for equal q-values are
+JSON, XML for values without maps, CSV for [][]string
+and slices of structs (see WriteCSVStructs),
+and plain text, followed by the formats registered with HTTPRegisterEncoder.
+A request without Accept header gets JSON.
+If no format is acceptable, then 406 Not Acceptable is
+responded and ErrHTTPNotAcceptable returned.
+Optional cache options enable ETag and conditional request handling,
+see HTTPCacheOptions.
+
+Usage example:
+
+	func handleUsers(w http.ResponseWriter, r *http.Request) {
+		err := dry.HTTPRespond(users, w, r)
+		if err != nil && !errors.Is(err, dry.ErrHTTPNotAcceptable) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+*/
+func HTTPRespond(value any, responseWriter http.ResponseWriter, request *http.Request, cache ...HTTPCacheOptions) error {
+	httpAddVary(responseWriter.Header(), "Accept")
+	encoder, available := httpNegotiateEncoder(request.Header.Get("Accept"), value)
+	if encoder == nil {
+		http.Error(responseWriter, "406 Not Acceptable, available: "+strings.Join(available, ", "), http.StatusNotAcceptable)
+		return ErrHTTPNotAcceptable
+	}
+	var buf bytes.Buffer
+	err := encoder.encode(&buf, value)
+	if err != nil {
+		return err
+	}
+	return httpRespondData(responseWriter, request, encoder.contentType, buf.Bytes(), cache)
+}
+
+// httpNegotiateEncoder returns the encoder with the highest
+// q-value in accept for value, or nil and the available media types
+func httpNegotiateEncoder(accept string, value any) (*httpEncoder, []string) {
+	httpEncodersMutex.RLock()
+	defer httpEncodersMutex.RUnlock()
+
+	var (
+		ranges    = httpParseQualityValues(accept)
+		best      *httpEncoder
+		bestQ     float64
+		available []string
+	)
+	for i := range httpEncoders {
+		encoder := &httpEncoders[i]
+		if encoder.canEncode != nil && !encoder.canEncode(value) {
+			continue
+		}
+		available = append(available, encoder.mediaType)
+		q := 1.0
+		if len(ranges) > 0 {
+			q = httpMediaTypeQuality(ranges, encoder.mediaType)
+		}
+		if q > bestQ {
+			best, bestQ = encoder, q
+		}
+	}
+	if best == nil {
+		return nil, available
+	}
+	// Copy because httpEncoders may be modified after unlocking
+	result := *best
+	return &result, available
+}
+
+// httpMediaTypeQuality returns the q-value of the most specific
+// media range in ranges that matches mediaType
+func httpMediaTypeQuality(ranges []httpQualityValue, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		rangeType, rangeSubtype, _ := strings.Cut(r.value, "/")
+		var s int
+		switch {
+		case strings.EqualFold(r.value, mediaType):
+			s = 2
+		case rangeSubtype == "*" && strings.EqualFold(rangeType, typ):
+			s = 1
+		case r.value == "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+type httpQualityValue struct {
+	value string
+	q     float64
+}
+
+// httpParseQualityValues parses a header value like
+// "text/html, application/json;q=0.9, */*;q=0.1"
+// and returns the values sorted by descending q-value.
+// Parameters other than q are removed from the values.
+func httpParseQualityValues(header string) []httpQualityValue {
+	var result []httpQualityValue
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, paramValue, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(paramValue), 64)
+				if err == nil && parsed >= 0 && parsed <= 1 {
+					q = parsed
+				}
+			}
+		}
+		result = append(result, httpQualityValue{value, q})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].q > result[j].q })
+	return result
+}
+
+func httpEncodeJSON(writer io.Writer, value any) error {
+	return json.NewEncoder(writer).Encode(value)
+}
+
+func httpEncodeXML(writer io.Writer, value any) error {
+	_, err := io.WriteString(writer, xml.Header)
+	if err != nil {
+		return err
+	}
+	return xml.NewEncoder(writer).Encode(value)
+}
+
+// httpCanEncodeXML returns false for values that encoding/xml
+// can't marshal like maps, so that another format is negotiated
+func httpCanEncodeXML(value any) bool {
+	return value != nil && httpXMLTypeSupported(reflect.TypeOf(value), make(map[reflect.Type]bool))
+}
+
+var xmlMarshalerType = reflect.TypeFor[xml.Marshaler]()
+
+func httpXMLTypeSupported(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] || t.Implements(xmlMarshalerType) || reflect.PointerTo(t).Implements(xmlMarshalerType) {
+		return true
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return false
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return httpXMLTypeSupported(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if (!field.IsExported() && !field.Anonymous) || field.Tag.Get("xml") == "-" {
+				continue
+			}
+			if !httpXMLTypeSupported(field.Type, visited) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func httpCanEncodeCSV(value any) bool {
+	if _, ok := value.([][]string); ok {
+		return true
+	}
+	t := reflect.TypeOf(value)
+	if t == nil || t.Kind() != reflect.Slice {
+		return false
+	}
+	_, _, err := csvStructType(t.Elem())
+	return err == nil
+}
+
+func httpEncodeCSV(writer io.Writer, value any) error {
+	if records, ok := value.([][]string); ok {
+		csvWriter := csv.NewWriter(writer)
+		return csvWriter.WriteAll(records)
+	}
+	return csvWriteStructs(writer, reflect.ValueOf(value), nil)
+}
+
+func httpEncodeText(writer io.Writer, value any) error {
+	var err error
+	switch v := value.(type) {
+	case string:
+		_, err = io.WriteString(writer, v)
+	case []byte:
+		_, err = writer.Write(v)
+	case fmt.Stringer:
+		_, err = io.WriteString(writer, v.String())
+	case error:
+		_, err = io.WriteString(writer, v.Error())
+	default:
+		err = PrettyPrint(writer, value, nil)
+	}
+	return err
+}
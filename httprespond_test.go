@@ -0,0 +1,109 @@
+package dry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type httpRespondTestUser struct {
+	Name string `json:"name" xml:"name" csv:"name"`
+	Age  int    `json:"age" xml:"age" csv:"age"`
+}
+
+func TestHTTPRespond(t *testing.T) {
+	users := []httpRespondTestUser{{"Alice", 30}, {"Bob", 40}}
+	respond := func(value any, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		request := httptest.NewRequest("GET", "/", nil)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		recorder := httptest.NewRecorder()
+		err := HTTPRespond(value, recorder, request)
+		if err != nil && !errors.Is(err, ErrHTTPNotAcceptable) {
+			t.Fatal(err)
+		}
+		return recorder
+	}
+
+	tests := []struct {
+		value       any
+		accept      string
+		contentType string
+		body        string
+	}{
+		{users, "", "application/json", `[{"name":"Alice","age":30},{"name":"Bob","age":40}]` + "\n"},
+		{users, "*/*", "application/json", `[{"name":"Alice","age":30},{"name":"Bob","age":40}]` + "\n"},
+		{users, "text/html, application/xml;q=0.9, */*;q=0.8", "application/xml", ""},
+		{users, "text/csv", "text/csv; charset=utf-8", "name,age\nAlice,30\nBob,40\n"},
+		{[][]string{{"a", "b"}, {"1", "2"}}, "text/*", "text/csv; charset=utf-8", "a,b\n1,2\n"},
+		{"hello", "text/*", "text/plain; charset=utf-8", "hello"},
+		{"hello", "text/plain;q=0.5, application/json;q=0.4", "text/plain; charset=utf-8", "hello"},
+		{"hello", "application/json;q=0, */*", "application/xml", ""},
+		{map[string]int{"a": 1}, "application/xml, application/json;q=0.9", "application/json", "{\"a\":1}\n"},
+		{[]map[string]int{{"a": 1}}, "application/xml, */*;q=0.9", "application/json", ""},
+	}
+	for _, test := range tests {
+		response := respond(test.value, test.accept)
+		if response.Code != http.StatusOK {
+			t.Errorf("Accept %q: status %d", test.accept, response.Code)
+			continue
+		}
+		if contentType := response.Header().Get("Content-Type"); contentType != test.contentType {
+			t.Errorf("Accept %q: Content-Type %q, expected %q", test.accept, contentType, test.contentType)
+		}
+		if test.body != "" && response.Body.String() != test.body {
+			t.Errorf("Accept %q: body %q, expected %q", test.accept, response.Body, test.body)
+		}
+	}
+
+	response := respond("hello", "text/csv")
+	if response.Code != http.StatusNotAcceptable {
+		t.Errorf("expected 406 for CSV of a string, got %d", response.Code)
+	}
+	if response := respond(users, "image/png"); response.Code != http.StatusNotAcceptable {
+		t.Errorf("expected 406, got %d", response.Code)
+	}
+
+	HTTPRegisterEncoder(
+		"application/x-test",
+		func(writer io.Writer, value any) error {
+			_, err := fmt.Fprintf(writer, "test:%d", len(value.([]httpRespondTestUser)))
+			return err
+		},
+		func(value any) bool {
+			_, ok := value.([]httpRespondTestUser)
+			return ok
+		},
+	)
+	defer HTTPRegisterEncoder("application/x-test", nil)
+	response = respond(users, "application/json;q=0.5, application/x-test")
+	if response.Header().Get("Content-Type") != "application/x-test" || response.Body.String() != "test:2" {
+		t.Errorf("custom encoder: %v %q", response.Header(), response.Body)
+	}
+	response = respond("hello", "application/json;q=0.5, application/x-test")
+	if response.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("custom encoder used for unsupported value: %v %q", response.Header(), response.Body)
+	}
+	if !strings.Contains(response.Header().Get("Vary"), "Accept") {
+		t.Error("missing Vary: Accept")
+	}
+}
+
+func Test_httpParseQualityValues(t *testing.T) {
+	values := httpParseQualityValues("text/html;level=1, application/json; q=0.5, */*;q=0.1, text/plain;q=0.9")
+	expected := []httpQualityValue{
+		{"text/html", 1},
+		{"text/plain", 0.9},
+		{"application/json", 0.5},
+		{"*/*", 0.1},
+	}
+	if fmt.Sprint(values) != fmt.Sprint(expected) {
+		t.Errorf("got %v, expected %v", values, expected)
+	}
+}
@@ -0,0 +1,103 @@
+package dry
+
+import (
+	"encoding/json"
+	"iter"
+	"net/http"
+)
+
+// HTTPJSONStreamOptions configures HTTPRespondJSONStream.
+// A nil *HTTPJSONStreamOptions is valid and uses the defaults.
+type HTTPJSONStreamOptions struct {
+	// NDJSON writes newline delimited JSON with Content-Type
+	// application/x-ndjson instead of a JSON array
+	NDJSON bool
+	// FlushEvery flushes the response after this many values,
+	// defaults to 1 to flush after every value
+	FlushEvery int
+}
+
+/*
+HTTPRespondJSONStream writes the values of seq as JSON array
+or newline delimited JSON to responseWriter while iterating seq,
+so large results don't have to be buffered in memory.
+The response is compressed if Content-Encoding from the request allows it
+and flushed after every HTTPJSONStreamOptions.FlushEvery values
+including the compression buffer.
+
+The iteration stops with the error of the request context
+if the client disconnects.
+If an error happens before the first value is written,
+then nothing is written to responseWriter and the caller
+can still respond with an error status.
+Errors that happen after the first value has been written
+can't be reported to the client with a status code,
+so a JSON array is left without closing bracket.
+
+Usage example:
+
+	func handleEvents(w http.ResponseWriter, r *http.Request) {
+		err := dry.HTTPRespondJSONStream(db.Events(r.Context()), w, r, &dry.HTTPJSONStreamOptions{NDJSON: true})
+		if err != nil {
+			log.Println(err)
+		}
+	}
+*/
+func HTTPRespondJSONStream[T any](seq iter.Seq[T], responseWriter http.ResponseWriter, request *http.Request, opts *HTTPJSONStreamOptions) (err error) {
+	if opts == nil {
+		opts = &HTTPJSONStreamOptions{}
+	}
+	flushEvery := max(opts.FlushEvery, 1)
+	NewHTTPCompressHandlerFromFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		if opts.NDJSON {
+			responseWriter.Header().Set("Content-Type", "application/x-ndjson")
+		} else {
+			responseWriter.Header().Set("Content-Type", "application/json")
+		}
+		flush := func() {
+			if flusher, ok := responseWriter.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+
+		var (
+			ctx   = request.Context()
+			count = 0
+			buf   []byte
+		)
+		if !opts.NDJSON {
+			buf = append(buf, '[')
+		}
+		for value := range seq {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			if count > 0 && !opts.NDJSON {
+				buf = append(buf, ',')
+			}
+			var data []byte
+			data, err = json.Marshal(value)
+			if err != nil {
+				return
+			}
+			buf = append(append(buf, data...), '\n')
+			_, err = responseWriter.Write(buf)
+			if err != nil {
+				return
+			}
+			buf = buf[:0]
+			count++
+			if count%flushEvery == 0 {
+				flush()
+			}
+		}
+		if !opts.NDJSON {
+			buf = append(buf, "]\n"...)
+		}
+		_, err = responseWriter.Write(buf)
+		if err == nil {
+			flush()
+		}
+	}).ServeHTTP(responseWriter, request)
+	return err
+}
@@ -0,0 +1,98 @@
+package dry
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHTTPRespondJSONStream(t *testing.T) {
+	next := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seq := func(yield func(int) bool) {
+			if !yield(1) {
+				return
+			}
+			// Wait until the client received the first value
+			select {
+			case <-next:
+			case <-time.After(5 * time.Second):
+				t.Error("first value was not flushed to the client")
+				return
+			}
+			for i := 2; i <= 3; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+		err := HTTPRespondJSONStream(seq, w, r, nil)
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	// The default transport requests and decompresses gzip
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if !response.Uncompressed {
+		t.Error("expected gzip compressed response")
+	}
+	reader := bufio.NewReader(response.Body)
+	first, err := reader.ReadString('\n')
+	if err != nil || first != "[1\n" {
+		t.Fatalf("expected first flushed value, got %q, %v", first, err)
+	}
+	close(next)
+	rest, err := io.ReadAll(reader)
+	if err != nil || string(rest) != ",2\n,3\n]\n" {
+		t.Errorf("invalid rest of stream %q, %v", rest, err)
+	}
+
+	type event struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	events := []event{{1, "a"}, {2, "b"}}
+	recorder := httptest.NewRecorder()
+	err = HTTPRespondJSONStream(slices.Values(events), recorder, httptest.NewRequest("GET", "/", nil), &HTTPJSONStreamOptions{NDJSON: true, FlushEvery: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Header().Get("Content-Type") != "application/x-ndjson" || recorder.Body.String() != "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n" {
+		t.Errorf("invalid NDJSON response %v %q", recorder.Header(), recorder.Body)
+	}
+	if !recorder.Flushed {
+		t.Error("response not flushed")
+	}
+
+	// Nothing is written if the first value can't be marshalled
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	err = HTTPRespondJSONStream(slices.Values([]any{func() {}}), recorder, request, nil)
+	if err == nil {
+		t.Fatal("expected marshalling error")
+	}
+	if recorder.Body.Len() != 0 || recorder.Flushed || recorder.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("response written before first value: %v %q", recorder.Header(), recorder.Body)
+	}
+	http.Error(recorder, err.Error(), http.StatusInternalServerError)
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	err = HTTPRespondJSONStream(slices.Values([]event(nil)), recorder, httptest.NewRequest("GET", "/", nil), nil)
+	if err != nil || recorder.Body.String() != "[]\n" {
+		t.Errorf("invalid empty array %q, %v", recorder.Body, err)
+	}
+}
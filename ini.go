@@ -0,0 +1,356 @@
+package dry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+/*
+INIFile holds the sections of an INI file.
+Keys before the first section header belong to the global section
+with the empty name.
+
+Supported syntax:
+
+	; comment
+	# comment
+	global = value
+	[section]
+	key = value
+	key: value
+	repeated = first
+	repeated = second
+	quoted = "  value with spaces  "
+	continued = first part \
+	    second part
+	multiline = first line
+	    second line
+
+Lines ending with a backslash are joined with the following line.
+Lines following a key that are indented deeper than the key,
+or that are indented and don't contain a '=' or ':' after a key,
+are appended to its value separated by a line break.
+Inline comments are not supported because values may contain ';' or '#'.
+*/
+type INIFile struct {
+	sections []*INISection
+}
+
+// INISection is a named section of an INIFile.
+type INISection struct {
+	Name   string
+	Values []INIValue
+}
+
+// INIValue is a key and value of an INISection.
+type INIValue struct {
+	Key   string
+	Value string
+}
+
+// ParseINI parses data as INI file.
+// Syntax errors are returned as *FileParseError.
+func ParseINI(data []byte) (*INIFile, error) {
+	ini := &INIFile{}
+	section := ini.AddSection("")
+	var (
+		last       *INIValue // for indented continuation lines
+		lastIndent int       // indentation of the line of last
+	)
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			last = nil
+			continue
+		}
+		if trimmed[0] == ';' || trimmed[0] == '#' {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if last != nil && indent > 0 && (indent > lastIndent || !iniIsKeyValue(trimmed)) {
+			last.Value += "\n" + trimmed
+			continue
+		}
+
+		for strings.HasSuffix(trimmed, `\`) {
+			trimmed = strings.TrimSuffix(trimmed, `\`)
+			if i+1 >= len(lines) {
+				return nil, &FileParseError{Line: lineNum, Message: "line continuation at end of file"}
+			}
+			i++
+			trimmed += strings.TrimSpace(lines[i])
+		}
+
+		if trimmed[0] == '[' {
+			end := strings.IndexByte(trimmed, ']')
+			if end == -1 {
+				return nil, &FileParseError{Line: lineNum, Message: "missing ']' in section header"}
+			}
+			if rest := strings.TrimSpace(trimmed[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, &FileParseError{Line: lineNum, Message: fmt.Sprintf("unexpected characters after section header: %s", rest)}
+			}
+			name := strings.TrimSpace(trimmed[1:end])
+			if name == "" {
+				return nil, &FileParseError{Line: lineNum, Message: "empty section name"}
+			}
+			section = ini.AddSection(name)
+			last = nil
+			continue
+		}
+
+		sep := strings.IndexAny(trimmed, "=:")
+		if sep == -1 {
+			return nil, &FileParseError{Line: lineNum, Message: fmt.Sprintf("missing '=' or ':' after key: %s", trimmed)}
+		}
+		key := strings.TrimSpace(trimmed[:sep])
+		if key == "" {
+			return nil, &FileParseError{Line: lineNum, Message: "empty key"}
+		}
+		value := strings.TrimSpace(trimmed[sep+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		section.Values = append(section.Values, INIValue{Key: key, Value: value})
+		last = &section.Values[len(section.Values)-1]
+		lastIndent = indent
+	}
+	return ini, nil
+}
+
+// iniIsKeyValue returns if line has a non empty key before '=' or ':'
+func iniIsKeyValue(line string) bool {
+	sep := strings.IndexAny(line, "=:")
+	return sep > 0 && strings.TrimSpace(line[:sep]) != ""
+}
+
+// FileGetINI reads filenameOrURL as INIFile.
+func FileGetINI(filenameOrURL string, timeout ...time.Duration) (*INIFile, error) {
+	data, err := FileGetBytes(filenameOrURL, timeout...)
+	if err != nil {
+		return nil, err
+	}
+	ini, err := ParseINI(data)
+	if err != nil {
+		return nil, fileParseErrorWithFilename(err, filenameOrURL)
+	}
+	return ini, nil
+}
+
+// FileSetINI writes ini to filename.
+func FileSetINI(filename string, ini *INIFile) error {
+	return FileSetBytes(filename, ini.Bytes())
+}
+
+// Sections returns all sections in the order of their first occurrence,
+// starting with the global section.
+func (ini *INIFile) Sections() []*INISection {
+	return ini.sections
+}
+
+// Section returns the section with name or nil.
+// Multiple sections with the same name are merged when parsing.
+func (ini *INIFile) Section(name string) *INISection {
+	for _, section := range ini.sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// AddSection returns the section with name,
+// or appends a new one if it does not exist.
+func (ini *INIFile) AddSection(name string) *INISection {
+	if section := ini.Section(name); section != nil {
+		return section
+	}
+	section := &INISection{Name: name}
+	ini.sections = append(ini.sections, section)
+	return section
+}
+
+// DeleteSection removes the section with name and returns if it existed.
+func (ini *INIFile) DeleteSection(name string) bool {
+	for i, section := range ini.sections {
+		if section.Name == name {
+			ini.sections = append(ini.sections[:i], ini.sections[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the last value of key in the section with name.
+func (ini *INIFile) Get(section, key string) (value string, ok bool) {
+	if s := ini.Section(section); s != nil {
+		return s.Get(key)
+	}
+	return "", false
+}
+
+// Bytes returns the text of the INI file.
+func (ini *INIFile) Bytes() []byte {
+	var buf bytes.Buffer
+	ini.WriteTo(&buf) //#nosec G104 -- bytes.Buffer does not return errors
+	return buf.Bytes()
+}
+
+func (ini *INIFile) String() string {
+	return string(ini.Bytes())
+}
+
+// WriteTo writes the INI file to writer.
+// Values with line breaks are written as indented continuation lines,
+// values with leading or trailing whitespace are quoted.
+func (ini *INIFile) WriteTo(writer io.Writer) (n int64, err error) {
+	var b strings.Builder
+	for _, section := range ini.sections {
+		if section.Name == "" && len(section.Values) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		if section.Name != "" {
+			fmt.Fprintf(&b, "[%s]\n", section.Name)
+		}
+		for _, v := range section.Values {
+			// Only the first line is on the key line and can be quoted
+			value, rest, multiLine := strings.Cut(v.Value, "\n")
+			if iniNeedsQuotes(value) {
+				value = `"` + value + `"`
+			}
+			if multiLine {
+				value += "\n" + rest
+			}
+			fmt.Fprintf(&b, "%s = %s\n", v.Key, strings.ReplaceAll(value, "\n", "\n    "))
+		}
+	}
+	m, err := io.WriteString(writer, b.String())
+	return int64(m), err
+}
+
+// iniNeedsQuotes returns if value would be parsed differently
+// without quotes because of surrounding whitespace or quotes
+// or a trailing backslash that would continue the line
+func iniNeedsQuotes(value string) bool {
+	return strings.TrimSpace(value) != value ||
+		strings.HasSuffix(value, `\`) ||
+		(len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0])
+}
+
+/*
+Bind sets the fields of the struct pointed to by structPtr from the INI file.
+Values of the global section are bound to top level fields,
+sections to nested struct fields with dot separated section names
+for deeper nesting like "[database.replica]".
+Section and key names are the names from `config` tags or the Go field names
+compared case insensitive and ignoring '_' and '-'.
+Repeated keys fill slice fields, a single value is split by the
+separator from the `sep` tag or ",".
+All problems are returned as ErrorList.
+*/
+func (ini *INIFile) Bind(structPtr any) error {
+	v := reflect.ValueOf(structPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("structPtr must be pointer to a struct, but is %T", structPtr)
+	}
+	sections := make(map[string]*INISection, len(ini.sections))
+	for _, section := range ini.sections {
+		sections[configNormalizeKey(section.Name)] = section
+	}
+
+	var errs ErrorList
+	for _, field := range bindStructFields(v.Elem(), "", "", nil, nil) {
+		sectionName := configNormalizeKey(strings.Join(field.Key[:len(field.Key)-1], "."))
+		section := sections[sectionName]
+		if section == nil {
+			continue
+		}
+		key := configNormalizeKey(field.Key[len(field.Key)-1])
+		var values []string
+		for _, v := range section.Values {
+			if configNormalizeKey(v.Key) == key {
+				values = append(values, v.Value)
+			}
+		}
+		var value any
+		switch len(values) {
+		case 0:
+			continue
+		case 1:
+			value = values[0]
+		default:
+			value = values
+		}
+		err := configSetValue(field.Value, value, field.Tag.Get("sep"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("INI section [%s] key %s for field %s: %w", section.Name, field.Key[len(field.Key)-1], field.Path, err))
+		}
+	}
+	return errs.Err()
+}
+
+// Get returns the last value of key.
+func (s *INISection) Get(key string) (value string, ok bool) {
+	for i := len(s.Values) - 1; i >= 0; i-- {
+		if s.Values[i].Key == key {
+			return s.Values[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// GetAll returns all values of a repeated key.
+func (s *INISection) GetAll(key string) (values []string) {
+	for _, v := range s.Values {
+		if v.Key == key {
+			values = append(values, v.Value)
+		}
+	}
+	return values
+}
+
+// Add appends a value for key, repeating the key if it already exists.
+func (s *INISection) Add(key, value string) {
+	s.Values = append(s.Values, INIValue{Key: key, Value: value})
+}
+
+// Set replaces all values of key with value at the position of its first
+// occurrence, or appends the key if it does not exist.
+func (s *INISection) Set(key, value string) {
+	for i := range s.Values {
+		if s.Values[i].Key == key {
+			s.Values[i].Value = value
+			s.deleteFrom(key, i+1)
+			return
+		}
+	}
+	s.Add(key, value)
+}
+
+// Delete removes all values of key and returns if any existed.
+func (s *INISection) Delete(key string) bool {
+	n := len(s.Values)
+	s.deleteFrom(key, 0)
+	return len(s.Values) != n
+}
+
+func (s *INISection) deleteFrom(key string, start int) {
+	values := s.Values[:start]
+	for _, v := range s.Values[start:] {
+		if v.Key != key {
+			values = append(values, v)
+		}
+	}
+	s.Values = values
+}
@@ -0,0 +1,112 @@
+package dry
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const iniTestData = `; global settings
+name = test
+timeout: 5s
+
+[database]
+host = "  localhost  "
+replica = r1
+replica = r2
+query = SELECT * \
+    FROM table
+
+[database.pool]
+max-conns = 10
+description = first line
+    second line
+`
+
+type iniTestConfig struct {
+	Name     string
+	Timeout  time.Duration
+	Database struct {
+		Host    string
+		Replica []string
+		Query   string
+		Pool    struct {
+			MaxConns int
+		}
+	}
+}
+
+func TestParseINI(t *testing.T) {
+	ini, err := ParseINI([]byte(iniTestData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := ini.Get("", "name"); value != "test" {
+		t.Errorf("invalid global name: %q", value)
+	}
+	database := ini.Section("database")
+	if database == nil {
+		t.Fatal("missing section database")
+	}
+	if replicas := database.GetAll("replica"); len(replicas) != 2 || replicas[1] != "r2" {
+		t.Errorf("invalid replicas: %#v", replicas)
+	}
+	if value, _ := database.Get("query"); value != "SELECT * FROM table" {
+		t.Errorf("invalid continued value: %q", value)
+	}
+	if value, _ := ini.Get("database.pool", "description"); value != "first line\nsecond line" {
+		t.Errorf("invalid multi-line value: %q", value)
+	}
+
+	reparsed, err := ParseINI(ini.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reparsed.String() != ini.String() {
+		t.Errorf("written INI not parsed identically:\n%s\n%s", ini, reparsed)
+	}
+
+	var config iniTestConfig
+	err = ini.Bind(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "test" ||
+		config.Timeout != 5*time.Second ||
+		config.Database.Host != "  localhost  " ||
+		len(config.Database.Replica) != 2 ||
+		config.Database.Pool.MaxConns != 10 {
+		t.Fatalf("Invalid values: %#v", config)
+	}
+
+	ini, err = ParseINI([]byte("[user]\n\tname = X\n\temail = Y\n\t\tsecond line\n\tcontinued\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := ini.Get("user", "name"); value != "X" {
+		t.Errorf("invalid indented name: %q", value)
+	}
+	if value, _ := ini.Get("user", "email"); value != "Y\nsecond line\ncontinued" {
+		t.Errorf("invalid indented email: %q", value)
+	}
+
+	// Values that need quotes to be read back unchanged
+	ini = &INIFile{}
+	section := ini.AddSection("paths")
+	for _, value := range []string{`C:\dir\`, ` space `, `"quoted"`, "first\\\nsecond\\", "\tfirst\nsecond"} {
+		section.Values = append(section.Values, INIValue{Key: "key", Value: value})
+	}
+	section.Values = append(section.Values, INIValue{Key: "next", Value: "value"})
+	reparsed, err = ParseINI(ini.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reparsed.Section("paths").Values, section.Values) {
+		t.Errorf("values not read back unchanged:\n%#v\n%#v", section.Values, reparsed.Section("paths").Values)
+	}
+
+	_, err = ParseINI([]byte("[ok]\na=1\n[broken\n"))
+	if parseErr, ok := err.(*FileParseError); !ok || parseErr.Line != 3 {
+		t.Fatalf("expected *FileParseError for line 3, got %#v", err)
+	}
+}
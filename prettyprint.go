@@ -0,0 +1,409 @@
+package dry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrettyPrintOptions configures PrettyPrint.
+// A nil *PrettyPrintOptions is valid and uses the defaults.
+type PrettyPrintOptions struct {
+	// Indent is used to indent nested lines, defaults to two spaces
+	Indent string
+	// MaxDepth limits the nesting depth of printed values,
+	// deeper values are printed as "...". Zero means no limit.
+	MaxDepth int
+	// Color adds ANSI terminal color escape sequences,
+	// see PrettyPrintColorSupported
+	Color bool
+	// Unexported prints unexported struct fields
+	Unexported bool
+	// GoSyntax forces Go syntax output even if the value
+	// could be marshalled as JSON
+	GoSyntax bool
+}
+
+const (
+	prettyColorReset   = "\x1b[0m"
+	prettyColorKey     = "\x1b[36m" // cyan
+	prettyColorString  = "\x1b[32m" // green
+	prettyColorNumber  = "\x1b[33m" // yellow
+	prettyColorKeyword = "\x1b[35m" // magenta
+	prettyColorType    = "\x1b[34m" // blue
+	prettyColorWarn    = "\x1b[31m" // red
+)
+
+// PrettyPrintColorSupported returns if w is a terminal
+// that should receive color escape sequences.
+// The NO_COLOR environment variable and TERM=dumb disable colors.
+func PrettyPrintColorSupported(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+/*
+PrettyPrint writes v in a human readable form to w followed by a newline.
+Values that can be marshalled as JSON are written as indented JSON.
+Values that JSON can't encode like channels, functions, cyclic pointers
+or maps with non string keys are written by a reflection based
+dumper in Go syntax that marks cycles with <cycle> instead of following them.
+The dumper is also used if opts requests a MaxDepth, unexported fields or GoSyntax.
+A byte slice containing valid JSON will be printed as that JSON.
+
+Usage example:
+
+	dry.PrettyPrint(os.Stdout, value, &dry.PrettyPrintOptions{
+		MaxDepth: 5,
+		Color:    dry.PrettyPrintColorSupported(os.Stdout),
+	})
+*/
+func PrettyPrint(w io.Writer, v any, opts *PrettyPrintOptions) error {
+	if opts == nil {
+		opts = &PrettyPrintOptions{}
+	}
+	indent := opts.Indent
+	if indent == "" {
+		indent = "  "
+	}
+
+	if !opts.GoSyntax && !opts.Unexported && opts.MaxDepth == 0 {
+		if b, ok := v.([]byte); ok && json.Valid(b) {
+			v = json.RawMessage(b)
+		}
+		if data, err := json.MarshalIndent(v, "", indent); err == nil {
+			if opts.Color {
+				data = prettyColorizeJSON(data)
+			}
+			_, err = fmt.Fprintf(w, "%s\n", data)
+			return err
+		}
+	}
+
+	p := prettyPrinter{
+		opts:    opts,
+		indent:  indent,
+		visited: make(map[prettyVisit]bool),
+	}
+	p.value(reflect.ValueOf(v), 0)
+	p.buf.WriteByte('\n')
+	_, err := p.buf.WriteTo(w)
+	return err
+}
+
+type prettyPrinter struct {
+	opts    *PrettyPrintOptions
+	indent  string
+	buf     bytes.Buffer
+	visited map[prettyVisit]bool
+}
+
+// prettyVisit identifies a pointer on the current path,
+// the type is needed because a struct and its first field share an address.
+type prettyVisit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+func (p *prettyPrinter) colored(color, s string) {
+	if p.opts.Color {
+		p.buf.WriteString(color)
+		p.buf.WriteString(s)
+		p.buf.WriteString(prettyColorReset)
+	} else {
+		p.buf.WriteString(s)
+	}
+}
+
+func (p *prettyPrinter) newline(depth int) {
+	p.buf.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		p.buf.WriteString(p.indent)
+	}
+}
+
+func (p *prettyPrinter) typeName(t reflect.Type) {
+	p.colored(prettyColorType, t.String())
+}
+
+func (p *prettyPrinter) value(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		p.colored(prettyColorKeyword, "nil")
+		return
+	}
+	if p.opts.MaxDepth > 0 && depth > p.opts.MaxDepth {
+		p.colored(prettyColorWarn, "...")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		p.colored(prettyColorKeyword, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.number(v, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.number(v, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		p.number(v, strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		p.number(v, strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits()))
+	case reflect.String:
+		if v.Type() != reflect.TypeOf("") {
+			p.typeName(v.Type())
+			p.buf.WriteByte('(')
+			p.colored(prettyColorString, strconv.Quote(v.String()))
+			p.buf.WriteByte(')')
+		} else {
+			p.colored(prettyColorString, strconv.Quote(v.String()))
+		}
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		p.buf.WriteByte('(')
+		p.typeName(v.Type())
+		p.buf.WriteString(")(")
+		if v.IsNil() {
+			p.colored(prettyColorKeyword, "nil")
+		} else {
+			p.colored(prettyColorNumber, fmt.Sprintf("%#x", v.Pointer()))
+		}
+		p.buf.WriteByte(')')
+	case reflect.Interface:
+		if v.IsNil() {
+			p.colored(prettyColorKeyword, "nil")
+			return
+		}
+		p.value(v.Elem(), depth)
+	case reflect.Ptr:
+		if v.IsNil() {
+			p.buf.WriteByte('(')
+			p.typeName(v.Type())
+			p.buf.WriteString(")(")
+			p.colored(prettyColorKeyword, "nil")
+			p.buf.WriteByte(')')
+			return
+		}
+		if p.enter(v) {
+			return
+		}
+		defer p.leave(v)
+		p.buf.WriteByte('&')
+		p.value(v.Elem(), depth)
+	case reflect.Slice:
+		if v.IsNil() {
+			p.typeName(v.Type())
+			p.buf.WriteByte('(')
+			p.colored(prettyColorKeyword, "nil")
+			p.buf.WriteByte(')')
+			return
+		}
+		if p.enter(v) {
+			return
+		}
+		defer p.leave(v)
+		p.list(v, depth)
+	case reflect.Array:
+		p.list(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			p.typeName(v.Type())
+			p.buf.WriteByte('(')
+			p.colored(prettyColorKeyword, "nil")
+			p.buf.WriteByte(')')
+			return
+		}
+		if p.enter(v) {
+			return
+		}
+		defer p.leave(v)
+		p.mapValue(v, depth)
+	case reflect.Struct:
+		p.structValue(v, depth)
+	default:
+		p.buf.WriteString(v.String())
+	}
+}
+
+// enter marks the pointer of v as visited and returns true
+// after writing a cycle marker if it was already visited.
+func (p *prettyPrinter) enter(v reflect.Value) (cycle bool) {
+	visit := prettyVisit{v.Pointer(), v.Type()}
+	if p.visited[visit] {
+		p.colored(prettyColorWarn, "<cycle "+v.Type().String()+">")
+		return true
+	}
+	p.visited[visit] = true
+	return false
+}
+
+func (p *prettyPrinter) leave(v reflect.Value) {
+	delete(p.visited, prettyVisit{v.Pointer(), v.Type()})
+}
+
+func (p *prettyPrinter) number(v reflect.Value, s string) {
+	switch v.Type() {
+	case reflect.TypeOf(int(0)), reflect.TypeOf(uint(0)), reflect.TypeOf(float64(0)), reflect.TypeOf(complex128(0)):
+		p.colored(prettyColorNumber, s)
+	default:
+		p.typeName(v.Type())
+		p.buf.WriteByte('(')
+		p.colored(prettyColorNumber, s)
+		p.buf.WriteByte(')')
+	}
+}
+
+func (p *prettyPrinter) list(v reflect.Value, depth int) {
+	p.typeName(v.Type())
+	if v.Len() == 0 {
+		p.buf.WriteString("{}")
+		return
+	}
+	p.buf.WriteByte('{')
+	if p.opts.MaxDepth > 0 && depth+1 > p.opts.MaxDepth {
+		p.colored(prettyColorWarn, "...")
+		p.buf.WriteByte('}')
+		return
+	}
+	for i := 0; i < v.Len(); i++ {
+		p.newline(depth + 1)
+		p.value(v.Index(i), depth+1)
+		p.buf.WriteByte(',')
+	}
+	p.newline(depth)
+	p.buf.WriteByte('}')
+}
+
+func (p *prettyPrinter) mapValue(v reflect.Value, depth int) {
+	p.typeName(v.Type())
+	if v.Len() == 0 {
+		p.buf.WriteString("{}")
+		return
+	}
+	p.buf.WriteByte('{')
+	if p.opts.MaxDepth > 0 && depth+1 > p.opts.MaxDepth {
+		p.colored(prettyColorWarn, "...")
+		p.buf.WriteByte('}')
+		return
+	}
+
+	// Format keys first to sort them by their printed representation
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		keyPrinter := prettyPrinter{opts: p.opts, indent: p.indent, visited: p.visited}
+		keyPrinter.value(iter.Key(), depth+1)
+		entries = append(entries, entry{key: keyPrinter.buf.String(), value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	for _, e := range entries {
+		p.newline(depth + 1)
+		p.buf.WriteString(e.key)
+		p.buf.WriteString(": ")
+		p.value(e.value, depth+1)
+		p.buf.WriteByte(',')
+	}
+	p.newline(depth)
+	p.buf.WriteByte('}')
+}
+
+func (p *prettyPrinter) structValue(v reflect.Value, depth int) {
+	t := v.Type()
+	if v.CanInterface() {
+		if stringer, ok := v.Interface().(fmt.Stringer); ok {
+			p.typeName(t)
+			p.buf.WriteByte('(')
+			p.colored(prettyColorString, strconv.Quote(stringer.String()))
+			p.buf.WriteByte(')')
+			return
+		}
+	}
+
+	p.typeName(t)
+	p.buf.WriteByte('{')
+	if p.opts.MaxDepth > 0 && depth+1 > p.opts.MaxDepth && t.NumField() > 0 {
+		p.colored(prettyColorWarn, "...")
+		p.buf.WriteByte('}')
+		return
+	}
+	written := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !p.opts.Unexported && !ReflectStructFieldIsExported(field) {
+			continue
+		}
+		p.newline(depth + 1)
+		p.colored(prettyColorKey, field.Name)
+		p.buf.WriteString(": ")
+		p.value(v.Field(i), depth+1)
+		p.buf.WriteByte(',')
+		written = true
+	}
+	if written {
+		p.newline(depth)
+	}
+	p.buf.WriteByte('}')
+}
+
+// prettyColorizeJSON adds ANSI color escape sequences
+// to the tokens of valid indented JSON.
+func prettyColorizeJSON(data []byte) []byte {
+	var b bytes.Buffer
+	b.Grow(len(data) * 2)
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			color := prettyColorString
+			if rest := bytes.TrimLeft(data[end:], " \t\r\n"); len(rest) > 0 && rest[0] == ':' {
+				color = prettyColorKey
+			}
+			b.WriteString(color)
+			b.Write(data[i:end])
+			b.WriteString(prettyColorReset)
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i
+			for end < len(data) && strings.IndexByte("+-.eE0123456789", data[end]) >= 0 {
+				end++
+			}
+			b.WriteString(prettyColorNumber)
+			b.Write(data[i:end])
+			b.WriteString(prettyColorReset)
+			i = end
+		case c == 't' || c == 'f' || c == 'n':
+			end := i
+			for end < len(data) && data[end] >= 'a' && data[end] <= 'z' {
+				end++
+			}
+			b.WriteString(prettyColorKeyword)
+			b.Write(data[i:end])
+			b.WriteString(prettyColorReset)
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.Bytes()
+}
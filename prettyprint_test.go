@@ -0,0 +1,48 @@
+package dry
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type prettyPrintNode struct {
+	Name   string
+	Next   *prettyPrintNode
+	Events chan int
+	hidden int
+}
+
+func TestPrettyPrint(t *testing.T) {
+	var buf bytes.Buffer
+	err := PrettyPrint(&buf, map[string]int{"a": 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "{\n  \"a\": 1\n}\n" {
+		t.Fatalf("unexpected JSON output: %q", buf.String())
+	}
+
+	node := &prettyPrintNode{Name: "a", Events: make(chan int), hidden: 7}
+	node.Next = node
+	buf.Reset()
+	err = PrettyPrint(&buf, node, &PrettyPrintOptions{Unexported: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{`Name: "a"`, "<cycle *dry.prettyPrintNode>", "(chan int)(0x", "hidden: 7"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("output does not contain %q:\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	err = PrettyPrint(&buf, map[any]any{1: []int{1, 2}}, &PrettyPrintOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); out != "map[interface {}]interface {}{\n  1: []int{...},\n}\n" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
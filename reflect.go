@@ -1,9 +1,14 @@
 package dry
 
 import (
+	"encoding"
 	"fmt"
+	"net/url"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 	"unicode"
 )
 
@@ -81,26 +86,46 @@ Example:
 	ReflectExportedStructFields(reflect.ValueOf(B{}))
 */
 func ReflectExportedStructFields(v reflect.Value) map[string]reflect.Value {
+	fields := ReflectExportedStructFieldList(v)
+	result := make(map[string]reflect.Value, len(fields))
+	for _, field := range fields {
+		result[field.Name] = field.Value
+	}
+	return result
+}
+
+// ReflectStructField is a struct field with its value
+// returned by ReflectExportedStructFieldList.
+type ReflectStructField struct {
+	reflect.StructField
+	Value reflect.Value
+}
+
+// ReflectExportedStructFieldList returns the exported fields of the struct v
+// in declaration order, inlining anonymous sub-structs like ReflectExportedStructFields.
+// Every field keeps its own reflect.StructField with its tags,
+// so fields of anonymous sub-structs with the same name as an outer field
+// are included as separate fields.
+func ReflectExportedStructFieldList(v reflect.Value) []ReflectStructField {
 	t := v.Type()
 	if t.Kind() != reflect.Struct {
 		panic(fmt.Errorf("Expected a struct, got %s", t))
 	}
-	result := make(map[string]reflect.Value)
-	reflectExportedStructFields(v, t, result)
-	return result
+	return reflectExportedStructFields(v, t, nil)
 }
 
-func reflectExportedStructFields(v reflect.Value, t reflect.Type, result map[string]reflect.Value) {
+func reflectExportedStructFields(v reflect.Value, t reflect.Type, result []ReflectStructField) []ReflectStructField {
 	for i := 0; i < t.NumField(); i++ {
 		structField := t.Field(i)
 		if ReflectStructFieldIsExported(structField) {
 			if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
-				reflectExportedStructFields(v.Field(i), structField.Type, result)
+				result = reflectExportedStructFields(v.Field(i), structField.Type, result)
 			} else {
-				result[structField.Name] = v.Field(i)
+				result = append(result, ReflectStructField{structField, v.Field(i)})
 			}
 		}
 	}
+	return result
 }
 
 func ReflectNameIsExported(name string) bool {
@@ -262,3 +287,164 @@ func IsZero(value any) bool {
 
 	panic(fmt.Errorf("Unknown value kind %T", value))
 }
+
+var (
+	reflectTypeOfDuration        = reflect.TypeOf(time.Duration(0))
+	reflectTypeOfURL             = reflect.TypeOf(url.URL{})
+	reflectTypeOfTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// reflectSetFromString parses str according to the type of v and sets v.
+// Pointers are allocated if nil, encoding.TextUnmarshaler, time.Duration
+// and url.URL are supported, and slices are split by sliceSep
+// with every element parsed separately.
+func reflectSetFromString(v reflect.Value, str, sliceSep string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return reflectSetFromString(v.Elem(), str, sliceSep)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(reflectTypeOfTextUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+	}
+
+	switch v.Type() {
+	case reflectTypeOfDuration:
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case reflectTypeOfURL:
+		u, err := url.Parse(str)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(str))
+			return nil
+		}
+		if str == "" {
+			v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+			return nil
+		}
+		if sliceSep == "" {
+			sliceSep = ","
+		}
+		parts := strings.Split(str, sliceSep)
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			err := reflectSetFromString(slice.Index(i), strings.TrimSpace(part), sliceSep)
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("can't set %s from string", v.Type())
+	}
+	return nil
+}
+
+// reflectIsStructToRecurse returns if t is a struct type that
+// should be recursed into instead of being parsed from a string.
+func reflectIsStructToRecurse(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		t != reflectTypeOfURL &&
+		!reflect.PointerTo(t).Implements(reflectTypeOfTextUnmarshaler)
+}
+
+var reflectTypeOfTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// reflectFormatString is the counterpart of reflectSetFromString
+// and formats v as string.
+// Nil pointers are formatted as empty string and slice elements
+// are joined with sliceSep.
+func reflectFormatString(v reflect.Value, sliceSep string) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Type().Implements(reflectTypeOfTextMarshaler) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(reflectTypeOfTextMarshaler) {
+		text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Type() {
+	case reflectTypeOfDuration:
+		return time.Duration(v.Int()).String(), nil
+	case reflectTypeOfURL:
+		u := v.Interface().(url.URL)
+		return u.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+		if sliceSep == "" {
+			sliceSep = ","
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			part, err := reflectFormatString(v.Index(i), sliceSep)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, sliceSep), nil
+	}
+	return "", fmt.Errorf("can't format %s as string", v.Type())
+}
@@ -2,7 +2,8 @@ package dry
 
 import (
 	// "strings"
-	// "fmt"
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -85,3 +86,23 @@ func Test_ReflectSetStructFieldsFromStringMap(t *testing.T) {
 		t.Fatalf("Invalid values: %#v", structPtr)
 	}
 }
+
+func Test_ReflectExportedStructFieldList(t *testing.T) {
+	type Base struct {
+		Name string `tag:"base"`
+		X    int
+	}
+	type Outer struct {
+		Name string `tag:"outer"`
+		Base
+		y int
+	}
+	fields := ReflectExportedStructFieldList(reflect.ValueOf(Outer{Name: "outer", Base: Base{Name: "base", X: 1}}))
+	var result []string
+	for _, field := range fields {
+		result = append(result, fmt.Sprintf("%s=%s:%v", field.Name, field.Tag.Get("tag"), field.Value))
+	}
+	if expected := []string{"Name=outer:outer", "Name=base:base", "X=:1"}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("got %v, expected %v", result, expected)
+	}
+}
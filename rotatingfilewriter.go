@@ -0,0 +1,326 @@
+package dry
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFileWriterOptions configures NewRotatingFileWriter.
+// A nil *RotatingFileWriterOptions is valid and never rotates
+// unless Rotate is called.
+type RotatingFileWriterOptions struct {
+	// MaxSize rotates the file before a write would make it
+	// larger than MaxSize bytes, zero disables size based rotation
+	MaxSize int64
+	// Interval rotates the file when it has been written to
+	// for longer than Interval since opening or the last rotation,
+	// zero disables time based rotation
+	Interval time.Duration
+	// MaxBackups is the number of rotated files to keep,
+	// zero keeps all
+	MaxBackups int
+	// Compress rotated files with gzip in the background
+	Compress bool
+	// Perm of created files, defaults to 0644
+	Perm os.FileMode
+}
+
+/*
+RotatingFileWriter is an io.WriteCloser that appends to a file
+and rotates it by size, time or both.
+Rotated files are renamed to the filename plus a timestamp suffix
+like "app.log.20060102-150405.000", with ".gz" appended if compressed.
+If a file was already rotated within the same millisecond,
+then a counter is appended like "app.log.20060102-150405.000.1".
+If the file can't be re-opened after a rotation,
+then the next Write tries to open it again.
+It is safe for concurrent use, every Write call is written
+completely to one file.
+
+Usage example:
+
+	writer, err := dry.NewRotatingFileWriter("app.log", &dry.RotatingFileWriterOptions{
+		MaxSize:    100 << 20,
+		Interval:   24 * time.Hour,
+		MaxBackups: 7,
+		Compress:   true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer writer.Close()
+	log.SetOutput(writer)
+*/
+type RotatingFileWriter struct {
+	filename string
+	opts     RotatingFileWriterOptions
+	now      func() time.Time
+
+	mutex  sync.Mutex
+	file   *os.File // nil if closed or failed to open after rotation
+	closed bool
+	size   int64
+	opened time.Time
+
+	// background compression and removal of backups
+	background      sync.WaitGroup
+	backgroundMutex sync.Mutex
+	backgroundErrs  ErrorList
+}
+
+// rotatingFileTimeFormat sorts lexically in chronological order
+const rotatingFileTimeFormat = "20060102-150405.000"
+
+// NewRotatingFileWriter opens or creates filename for appending.
+func NewRotatingFileWriter(filename string, opts *RotatingFileWriterOptions) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{filename: filename, now: time.Now}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Perm == 0 {
+		w.opts.Perm = 0644
+	}
+	err := w.open()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Filename returns the filename passed to NewRotatingFileWriter.
+func (w *RotatingFileWriter) Filename() string {
+	return w.filename
+}
+
+// Write appends p to the file after rotating it if necessary.
+func (w *RotatingFileWriter) Write(p []byte) (n int, err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	err = w.reopen()
+	if err != nil {
+		return 0, err
+	}
+	if w.size > 0 && ((w.opts.MaxSize > 0 && w.size+int64(len(p)) > w.opts.MaxSize) ||
+		(w.opts.Interval > 0 && w.now().Sub(w.opened) >= w.opts.Interval)) {
+		err = w.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately if it is not empty.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	err := w.reopen()
+	if err != nil {
+		return err
+	}
+	if w.size == 0 {
+		return nil
+	}
+	return w.rotate()
+}
+
+// Close closes the file and waits for the background compression
+// of rotated files. Errors of the background processing
+// since the last Close are returned.
+func (w *RotatingFileWriter) Close() error {
+	w.mutex.Lock()
+	var err error
+	switch {
+	case w.closed:
+		err = os.ErrClosed
+	case w.file != nil:
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.closed = true
+	w.mutex.Unlock()
+
+	w.background.Wait()
+	w.backgroundMutex.Lock()
+	defer w.backgroundMutex.Unlock()
+	errs := w.backgroundErrs
+	w.backgroundErrs = nil
+	if err != nil {
+		errs = append(ErrorList{err}, errs...)
+	}
+	return errs.Err()
+}
+
+// Backups returns the filenames of the rotated files
+// from oldest to newest.
+func (w *RotatingFileWriter) Backups() ([]string, error) {
+	dir := filepath.Dir(w.filename)
+	prefix := filepath.Base(w.filename) + "."
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if _, _, ok := rotatingFileParseSuffix(strings.TrimPrefix(name, prefix)); ok {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	// Sort by timestamp and counter
+	slices.SortFunc(backups, func(a, b string) int {
+		aTime, aCount, _ := rotatingFileParseSuffix(strings.TrimPrefix(filepath.Base(a), prefix))
+		bTime, bCount, _ := rotatingFileParseSuffix(strings.TrimPrefix(filepath.Base(b), prefix))
+		if c := strings.Compare(aTime, bTime); c != 0 {
+			return c
+		}
+		return aCount - bCount
+	})
+	return backups, nil
+}
+
+// rotatingFileParseSuffix parses the timestamp and optional counter
+// of a backup filename suffix like "20060102-150405.000.1.gz"
+func rotatingFileParseSuffix(suffix string) (timestamp string, count int, ok bool) {
+	suffix = strings.TrimSuffix(suffix, ".gz")
+	if len(suffix) < len(rotatingFileTimeFormat) {
+		return "", 0, false
+	}
+	timestamp, counter := suffix[:len(rotatingFileTimeFormat)], suffix[len(rotatingFileTimeFormat):]
+	if _, err := time.Parse(rotatingFileTimeFormat, timestamp); err != nil {
+		return "", 0, false
+	}
+	if counter != "" {
+		digits, found := strings.CutPrefix(counter, ".")
+		count, err := strconv.Atoi(digits)
+		if !found || err != nil || count < 1 {
+			return "", 0, false
+		}
+		return timestamp, count, true
+	}
+	return timestamp, 0, true
+}
+
+// reopen opens the file if a previous open failed,
+// must be called with locked mutex
+func (w *RotatingFileWriter) reopen() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file != nil {
+		return nil
+	}
+	return w.open()
+}
+
+func (w *RotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.opts.Perm) //#nosec G304
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close() //#nosec G104
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.opened = w.now()
+	return nil
+}
+
+// rotate must be called with locked mutex
+func (w *RotatingFileWriter) rotate() error {
+	err := w.file.Close()
+	if err != nil {
+		return err
+	}
+	w.file = nil
+	timestamp := w.filename + "." + w.now().Format(rotatingFileTimeFormat)
+	backup := timestamp
+	for i := 1; FileExists(backup) || FileExists(backup+".gz"); i++ {
+		// Rotated more than once per millisecond
+		backup = timestamp + "." + strconv.Itoa(i)
+	}
+	err = os.Rename(w.filename, backup)
+	if err != nil {
+		return errors.Join(err, w.open())
+	}
+	// If open fails, then the next Write tries again
+	err = w.open()
+
+	w.background.Add(1)
+	go func() {
+		defer w.background.Done()
+		// Serialize background processing of multiple rotations
+		w.backgroundMutex.Lock()
+		defer w.backgroundMutex.Unlock()
+		if w.opts.Compress {
+			err := rotatingFileCompress(backup)
+			// The backup might already be removed by
+			// the processing of a later rotation
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				w.backgroundErrs = append(w.backgroundErrs, err)
+			}
+		}
+		if w.opts.MaxBackups > 0 {
+			backups, err := w.Backups()
+			if err != nil {
+				w.backgroundErrs = append(w.backgroundErrs, err)
+			}
+			for len(backups) > w.opts.MaxBackups {
+				err = os.Remove(backups[0])
+				if err != nil {
+					w.backgroundErrs = append(w.backgroundErrs, err)
+				}
+				backups = backups[1:]
+			}
+		}
+	}()
+	return err
+}
+
+// rotatingFileCompress replaces filename with filename+".gz"
+func rotatingFileCompress(filename string) (err error) {
+	source, err := os.Open(filename) //#nosec G304
+	if err != nil {
+		return err
+	}
+	defer source.Close() //#nosec G307
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	dest, err := os.OpenFile(filename+".gz", os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm()) //#nosec G304
+	if err != nil {
+		return err
+	}
+	writer := Gzip.GetWriter(dest)
+	_, err = io.Copy(writer, source)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	Gzip.ReturnWriter(writer)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename + ".gz") //#nosec G104
+		return err
+	}
+	return os.Remove(filename)
+}
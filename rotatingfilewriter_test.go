@@ -0,0 +1,157 @@
+package dry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileWriterSize(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	writer, err := NewRotatingFileWriter(filename, &RotatingFileWriterOptions{MaxSize: 100, MaxBackups: 3, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const writers, lines = 4, 25
+	line := strings.Repeat("x", 19) + "\n"
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < lines; j++ {
+				_, err := fmt.Fprint(writer, line)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("expected 3 backups, got %v", backups)
+	}
+	for _, backup := range backups {
+		if !strings.HasSuffix(backup, ".gz") {
+			t.Errorf("backup not compressed: %s", backup)
+			continue
+		}
+		data, err := FileGetBytes(backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data = BytesUnGzip(data); string(data) != strings.Repeat(line, 5) {
+			t.Errorf("invalid backup content: %q", data)
+		}
+	}
+	if size := FileSize(filename); size != 100 {
+		t.Errorf("expected current file size 100, got %d", size)
+	}
+
+	_, err = writer.Write([]byte(line))
+	if err == nil {
+		t.Error("expected error writing to closed writer")
+	}
+}
+
+func TestRotatingFileWriterInterval(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	writer, err := NewRotatingFileWriter(filename, &RotatingFileWriterOptions{Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	writer.now = func() time.Time { return now }
+	writer.opened = now
+
+	writer.Write([]byte("first\n"))
+	now = now.Add(59 * time.Minute)
+	writer.Write([]byte("second\n"))
+	now = now.Add(time.Minute)
+	writer.Write([]byte("third\n"))
+	now = now.Add(time.Minute)
+	writer.Rotate()
+	writer.Write([]byte("fourth\n"))
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := writer.Backups()
+	if len(backups) != 2 || filepath.Base(backups[0]) != "app.log.20240101-010000.000" {
+		t.Fatalf("invalid backups: %v", backups)
+	}
+	if data, _ := FileGetString(backups[0]); data != "first\nsecond\n" {
+		t.Errorf("invalid backup content: %q", data)
+	}
+	if data, _ := FileGetString(backups[1]); data != "third\n" {
+		t.Errorf("invalid backup content: %q", data)
+	}
+	if data, _ := FileGetString(filename); data != "fourth\n" {
+		t.Errorf("invalid current content: %q", data)
+	}
+}
+
+func TestRotatingFileWriterSameTime(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	writer, err := NewRotatingFileWriter(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	writer.now = func() time.Time { return now }
+
+	for i := 0; i < 11; i++ {
+		fmt.Fprintf(writer, "%d\n", i)
+		err = writer.Rotate()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, _ := writer.Backups()
+	if len(backups) != 11 || filepath.Base(backups[1]) != "app.log.20240101-000000.000.1" {
+		t.Fatalf("invalid backups: %v", backups)
+	}
+	for i, backup := range backups {
+		if data, _ := FileGetString(backup); data != fmt.Sprintf("%d\n", i) {
+			t.Errorf("invalid content of %s: %q", backup, data)
+		}
+	}
+
+	// Failed open is retried by the next Write
+	writer.mutex.Lock()
+	writer.file.Close()
+	writer.file = nil
+	writer.mutex.Unlock()
+	os.Remove(filename)
+	os.Mkdir(filename, 0755)
+	if _, err = writer.Write([]byte("x\n")); err == nil {
+		t.Fatal("expected error opening directory")
+	}
+	os.Remove(filename)
+	if _, err = writer.Write([]byte("x\n")); err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := FileGetString(filename); data != "x\n" {
+		t.Errorf("invalid current content: %q", data)
+	}
+}
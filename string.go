@@ -113,6 +113,8 @@ func StringReplaceHTMLTags(text, replacement string) (plainText string) {
 // StringMD5Hex returns the hex encoded MD5 hash of data.
 // WARNING: MD5 is cryptographically broken and should NOT be used for security purposes.
 // This function is suitable for checksums, cache keys, and other non-security applications only.
+//
+// Deprecated: Use StringHash(data, HashMD5).
 func StringMD5Hex(data string) string {
 	hash := md5.New() //#nosec
 	hash.Write([]byte(data))
@@ -300,6 +302,25 @@ func StringToLowerCamelCase(str string) string {
 	return b.String()
 }
 
+// StringToUpperSnakeCase converts a camel case identifier
+// to upper case words separated by underscores,
+// keeping acronyms together: "HTTPServerPort" becomes "HTTP_SERVER_PORT".
+func StringToUpperSnakeCase(str string) string {
+	runes := []rune(str)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prev != '_' && (unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower)) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
 func StringMapSortedKeys(m map[string]string) []string {
 	keys := make([]string, 0, len(m))
 	for key := range m {
@@ -146,3 +146,20 @@ func Test_StringSplitOnce(t *testing.T) {
 		}
 	}
 }
+
+func Test_StringToUpperSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"":               "",
+		"Port":           "PORT",
+		"maxConns":       "MAX_CONNS",
+		"HTTPServerPort": "HTTP_SERVER_PORT",
+		"URL":            "URL",
+		"Retry3Times":    "RETRY3_TIMES",
+		"Already_Snake":  "ALREADY_SNAKE",
+	}
+	for input, expected := range tests {
+		if result := StringToUpperSnakeCase(input); result != expected {
+			t.Errorf("StringToUpperSnakeCase(%q) = %q, expected %q", input, result, expected)
+		}
+	}
+}
@@ -212,6 +212,17 @@ func (s *SyncMap) Delete(key string) {
 	s.mutex.Unlock()
 }
 
+// Clone returns a copy of the underlying map.
+func (s *SyncMap) Clone() map[string]any {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	clone := make(map[string]any, len(s.m))
+	for key, value := range s.m {
+		clone[key] = value
+	}
+	return clone
+}
+
 func (s *SyncMap) Int(key string) *SyncInt {
 	return s.Get(key).(*SyncInt)
 }
@@ -269,6 +280,17 @@ func (s *SyncStringMap) Get(key string) string {
 	return s.m[key]
 }
 
+// Clone returns a copy of the underlying map.
+func (s *SyncStringMap) Clone() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	clone := make(map[string]string, len(s.m))
+	for key, value := range s.m {
+		clone[key] = value
+	}
+	return clone
+}
+
 func (s *SyncStringMap) Add(key string, value string) {
 	s.mutex.Lock()
 	s.m[key] = value
@@ -0,0 +1,305 @@
+package dry
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidatorFunc validates value for a rule with the rule parameter
+// after "=" in the validate tag, or an empty string.
+// The returned error message is used as ValidationError.Message.
+type ValidatorFunc func(value reflect.Value, param string) error
+
+// ValidationError is a failed rule of a struct field
+// returned by Validate as element of an ErrorList.
+type ValidationError struct {
+	// Field path like "Address.City" or "Items[2].Name"
+	Field   string
+	Rule    string
+	Param   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+var (
+	validatorsMutex sync.RWMutex
+	validators      = map[string]ValidatorFunc{
+		"required": validateRequired,
+		"min":      validateMin,
+		"max":      validateMax,
+		"email":    validateEmail,
+		"oneof":    validateOneOf,
+	}
+)
+
+// RegisterValidator registers a validator for a rule name
+// that can be used in validate tags.
+// The built-in rules "required", "min", "max", "email" and "oneof"
+// can be replaced, a nil validator removes a rule.
+func RegisterValidator(rule string, validator ValidatorFunc) {
+	validatorsMutex.Lock()
+	defer validatorsMutex.Unlock()
+	if validator == nil {
+		delete(validators, rule)
+	} else {
+		validators[rule] = validator
+	}
+}
+
+/*
+Validate checks the exported fields of the struct or pointer to struct v
+against the comma separated rules of their validate tags and recurses into
+nested structs and elements of slices, arrays and maps.
+Values referenced multiple times, also by cyclic references,
+are validated only once.
+The failed rules are returned as ErrorList of *ValidationError.
+
+Built-in rules:
+
+	required   value must not be the zero value, slices and maps must not be empty
+	omitempty  skip the following rules if the value is the zero value
+	min=n      numbers must be >= n, strings, slices and maps must have a length >= n
+	max=n      numbers must be <= n, strings, slices and maps must have a length <= n
+	email      string must be an email address without name
+	oneof=a b  value formatted as string must be one of the space separated values
+
+For time.Duration fields min and max can be durations like "1s".
+Rules other than required are not applied to nil pointers.
+See RegisterValidator for custom rules.
+
+Usage example:
+
+	type Config struct {
+		Name  string   `validate:"required,max=100"`
+		Mode  string   `validate:"oneof=dev prod"`
+		Admin string   `validate:"omitempty,email"`
+		Ports []int    `validate:"min=1"`
+		DB    DBConfig // validated recursively
+	}
+
+	err := dry.Validate(&config)
+*/
+func Validate(v any) error {
+	return validate(v, nil)
+}
+
+// validate is Validate with the path elements of struct fields
+// named by fieldName instead of the Go field name if not nil
+func validate(v any, fieldName func(reflect.StructField) string) error {
+	value := reflect.ValueOf(v)
+	state := &validation{fieldName: fieldName, visited: make(map[validationVisit]bool)}
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		state.visit(value)
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("Validate expects a struct or pointer to struct, got %T", v)
+	}
+	state.validateStruct(value, "")
+	return state.errs.Err()
+}
+
+// validation holds the state of a Validate call
+type validation struct {
+	fieldName func(reflect.StructField) string
+	// visited pointers, slices and maps to stop at cyclic references
+	visited map[validationVisit]bool
+	errs    ErrorList
+}
+
+type validationVisit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// visit returns false if the pointer, slice or map v was already visited
+func (s *validation) visit(v reflect.Value) bool {
+	key := validationVisit{v.Pointer(), v.Type()}
+	if s.visited[key] {
+		return false
+	}
+	s.visited[key] = true
+	return true
+}
+
+func (s *validation) validateStruct(v reflect.Value, path string) {
+	for _, field := range ReflectExportedStructFieldList(v) {
+		tag := field.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		fieldPath := field.Name
+		if s.fieldName != nil {
+			fieldPath = s.fieldName(field.StructField)
+		}
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+		s.validateValue(field.Value, fieldPath, tag)
+	}
+}
+
+func (s *validation) validateValue(v reflect.Value, path, tag string) {
+	if tag != "" {
+		for _, rule := range strings.Split(tag, ",") {
+			rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			if rule == "omitempty" {
+				if v.IsZero() {
+					return
+				}
+				continue
+			}
+			if rule != "required" && v.Kind() == reflect.Ptr && v.IsNil() {
+				continue
+			}
+			validatorsMutex.RLock()
+			validator, ok := validators[rule]
+			validatorsMutex.RUnlock()
+			if !ok {
+				s.errs = append(s.errs, &ValidationError{Field: path, Rule: rule, Param: param, Message: fmt.Sprintf("unknown validation rule %q", rule)})
+				continue
+			}
+			err := validator(v, param)
+			if err != nil {
+				s.errs = append(s.errs, &ValidationError{Field: path, Rule: rule, Param: param, Message: err.Error()})
+			}
+		}
+	}
+
+	// Recurse into nested values
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() || (v.Kind() == reflect.Ptr && !s.visit(v)) {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if reflectIsStructToRecurse(v.Type()) {
+			s.validateStruct(v, path)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.Len() == 0 || !s.visit(v)) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			s.validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), "")
+		}
+	case reflect.Map:
+		if v.Len() == 0 || !s.visit(v) {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			s.validateValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), "")
+		}
+	}
+}
+
+func validateRequired(v reflect.Value, param string) error {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return errors.New("is required")
+		}
+	default:
+		if v.IsZero() {
+			return errors.New("is required")
+		}
+	}
+	return nil
+}
+
+func validateMin(v reflect.Value, param string) error {
+	return validateCompare(v, param, "min", func(a, b float64) bool { return a >= b })
+}
+
+func validateMax(v reflect.Value, param string) error {
+	return validateCompare(v, param, "max", func(a, b float64) bool { return a <= b })
+}
+
+// validateCompare checks the number or length of v against param with ok
+func validateCompare(v reflect.Value, param, rule string, ok func(value, limit float64) bool) error {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	var (
+		value float64
+		limit float64
+		err   error
+		unit  string // of lengths
+	)
+	if v.Type() == reflectTypeOfDuration {
+		var d time.Duration
+		d, err = time.ParseDuration(param)
+		value, limit = float64(v.Int()), float64(d)
+	} else {
+		limit, err = strconv.ParseFloat(param, 64)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			value = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			value = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			value = v.Float()
+		case reflect.String:
+			value, unit = float64(utf8.RuneCountInString(v.String())), "characters"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			value, unit = float64(v.Len()), "elements"
+		default:
+			return fmt.Errorf("%s is not supported for %s", rule, v.Type())
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s parameter %q", rule, param)
+	}
+	if ok(value, limit) {
+		return nil
+	}
+	switch {
+	case unit != "" && rule == "min":
+		return fmt.Errorf("must have at least %s %s", param, unit)
+	case unit != "":
+		return fmt.Errorf("must have at most %s %s", param, unit)
+	case rule == "min":
+		return fmt.Errorf("must be at least %s", param)
+	default:
+		return fmt.Errorf("must be at most %s", param)
+	}
+}
+
+func validateEmail(v reflect.Value, param string) error {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return fmt.Errorf("email is not supported for %s", v.Type())
+	}
+	address, err := mail.ParseAddress(v.String())
+	if err != nil || address.Address != v.String() {
+		return errors.New("must be an email address")
+	}
+	return nil
+}
+
+func validateOneOf(v reflect.Value, param string) error {
+	str, err := reflectFormatString(v, ",")
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(strings.Fields(param), str) {
+		return fmt.Errorf("must be one of %s", strings.Join(strings.Fields(param), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package dry
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type validateTestAddress struct {
+	City string `validate:"required"`
+	Zip  string `validate:"min=5,max=5"`
+}
+
+type validateTestConfig struct {
+	Name      string                          `validate:"required,max=10"`
+	Mode      string                          `validate:"oneof=dev prod"`
+	Level     int                             `validate:"oneof=1 2 3"`
+	Admin     string                          `validate:"omitempty,email"`
+	Ports     []int                           `validate:"required,max=2"`
+	Timeout   time.Duration                   `validate:"min=1s,max=1m"`
+	Ratio     *float64                        `validate:"min=0,max=1"`
+	Address   validateTestAddress             `validate:"-"`
+	Addresses []validateTestAddress           ``
+	ByName    map[string]*validateTestAddress ``
+	Even      int                             `validate:"even"`
+}
+
+func TestValidate(t *testing.T) {
+	RegisterValidator("even", func(value reflect.Value, param string) error {
+		if value.Int()%2 != 0 {
+			return errors.New("must be even")
+		}
+		return nil
+	})
+	defer RegisterValidator("even", nil)
+
+	valid := validateTestConfig{
+		Name:      "test",
+		Mode:      "prod",
+		Level:     2,
+		Ports:     []int{80},
+		Timeout:   time.Second,
+		Addresses: []validateTestAddress{{City: "Vienna", Zip: "01010"}},
+		ByName:    map[string]*validateTestAddress{"home": {City: "Graz", Zip: "08010"}},
+	}
+	err := Validate(&valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ratio := 1.5
+	invalid := validateTestConfig{
+		Name:      "more than ten",
+		Mode:      "test",
+		Level:     4,
+		Admin:     "Admin <admin@example.com>",
+		Ports:     []int{1, 2, 3},
+		Timeout:   time.Hour,
+		Ratio:     &ratio,
+		Addresses: []validateTestAddress{{City: "Vienna", Zip: "01010"}, {Zip: "1"}},
+		ByName:    map[string]*validateTestAddress{"home": {City: "Graz"}},
+		Even:      3,
+	}
+	err = Validate(invalid)
+	errs := AsErrorList(err)
+	var messages []string
+	for _, e := range errs {
+		var validationErr *ValidationError
+		if !errors.As(e, &validationErr) {
+			t.Fatalf("expected *ValidationError, got %T", e)
+		}
+		messages = append(messages, validationErr.Error())
+	}
+	expected := []string{
+		"Name: must have at most 10 characters",
+		"Mode: must be one of dev, prod",
+		"Level: must be one of 1, 2, 3",
+		"Admin: must be an email address",
+		"Ports: must have at most 2 elements",
+		"Timeout: must be at most 1m",
+		"Ratio: must be at most 1",
+		"Addresses[1].City: is required",
+		"Addresses[1].Zip: must have at least 5 characters",
+		"ByName[home].Zip: must have at least 5 characters",
+		"Even: must be even",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got errors:\n%s\nexpected:\n%s", strings.Join(messages, "\n"), strings.Join(expected, "\n"))
+	}
+
+	var empty struct {
+		Name  string `validate:"required"`
+		Ports []int  `validate:"required"`
+		Ptr   *int   `validate:"required"`
+		Other string `validate:"unknown"`
+	}
+	if errs := AsErrorList(Validate(&empty)); fmt.Sprint(errs) != fmt.Sprint(ErrorList{
+		&ValidationError{Field: "Name", Message: "is required"},
+		&ValidationError{Field: "Ports", Message: "is required"},
+		&ValidationError{Field: "Ptr", Message: "is required"},
+		&ValidationError{Field: "Other", Message: `unknown validation rule "unknown"`},
+	}) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	type Base struct {
+		Name string
+		Zip  string `validate:"min=5"`
+	}
+	type Outer struct {
+		Name string `validate:"required"`
+		Base
+	}
+	if err := Validate(&Outer{Name: "x", Base: Base{Zip: "12345"}}); err != nil {
+		t.Errorf("embedded field validated with the tag of the outer field: %v", err)
+	}
+	if err := Validate(&Outer{Name: "x", Base: Base{Zip: "1"}}); err == nil {
+		t.Error("expected error for embedded field")
+	}
+
+	if Validate("string") == nil {
+		t.Error("expected error for non struct")
+	}
+}
+
+func TestValidateCyclic(t *testing.T) {
+	type Node struct {
+		Name   string `validate:"required"`
+		Parent *Node
+		Kids   []*Node
+		Meta   map[string]any
+	}
+	root := &Node{Name: "root", Meta: map[string]any{}}
+	root.Meta["self"] = root.Meta
+	root.Kids = []*Node{{Parent: root}, {Name: "b", Parent: root}}
+	root.Kids[1].Kids = root.Kids
+
+	errs := AsErrorList(Validate(root))
+	if fmt.Sprint(errs) != fmt.Sprint(ErrorList{&ValidationError{Field: "Kids[0].Name", Message: "is required"}}) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
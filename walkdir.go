@@ -0,0 +1,354 @@
+package dry
+
+import (
+	"errors"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WalkDirSymlinks is the policy for symbolic links used by WalkDir.
+type WalkDirSymlinks int
+
+const (
+	// WalkDirSymlinksReport yields symbolic links as entries
+	// with the fs.FileInfo of the link itself without following them.
+	WalkDirSymlinksReport WalkDirSymlinks = iota
+	// WalkDirSymlinksFollow yields the target of symbolic links
+	// and descends into linked directories.
+	// Links to a directory that is already being walked are not followed
+	// to prevent endless recursion.
+	WalkDirSymlinksFollow
+	// WalkDirSymlinksSkip ignores symbolic links.
+	WalkDirSymlinksSkip
+)
+
+// WalkDirOptions configures WalkDir.
+// A nil *WalkDirOptions is valid and uses the defaults.
+//
+// Patterns use '/' as separator and are matched against the path
+// relative to the walked root directory, patterns without '/'
+// are also matched against the base name at any depth.
+// Besides the syntax of path.Match, a "**" path segment
+// matches zero or more directories.
+type WalkDirOptions struct {
+	// Include limits the yielded entries to the ones matching
+	// at least one pattern, directories are still descended into.
+	// If empty, all entries are yielded.
+	Include []string
+	// Exclude skips matching entries and does not descend
+	// into matching directories
+	Exclude []string
+	// IgnoreFiles are names of files like ".gitignore"
+	// with ignore patterns in .gitignore syntax that are read
+	// from every walked directory and apply to its descendants
+	IgnoreFiles []string
+	// MaxDepth limits the directory levels to walk,
+	// 1 yields only the entries of the root directory,
+	// 0 means unlimited
+	MaxDepth int
+	// Symlinks is the policy for symbolic links,
+	// defaults to WalkDirSymlinksReport
+	Symlinks WalkDirSymlinks
+	// Dirs also yields directories, not only files
+	Dirs bool
+}
+
+// WalkDirEntry is a file or directory yielded by WalkDir.
+type WalkDirEntry struct {
+	// Path is the root directory joined with RelPath
+	Path string
+	// RelPath is the '/' separated path relative to the root directory
+	RelPath string
+	// Depth is 1 for entries of the root directory
+	Depth int
+	Info  fs.FileInfo
+}
+
+/*
+WalkDir returns an iterator over the files below the directory root
+in lexical order, filtered by opts.
+Errors for unreadable directories or files are yielded
+with a WalkDirEntry that has only Path set, iteration continues
+after an error unless the loop is stopped.
+
+Usage example:
+
+	opts := &dry.WalkDirOptions{
+		Include:     []string{"*.go"},
+		Exclude:     []string{"vendor", "*_test.go"},
+		IgnoreFiles: []string{".gitignore"},
+	}
+	for entry, err := range dry.WalkDir(".", opts) {
+		if err != nil {
+			return err
+		}
+		fmt.Println(entry.Path, entry.Info.Size())
+	}
+*/
+func WalkDir(root string, opts *WalkDirOptions) iter.Seq2[WalkDirEntry, error] {
+	if opts == nil {
+		opts = &WalkDirOptions{}
+	}
+	return func(yield func(WalkDirEntry, error) bool) {
+		rootInfo, err := os.Stat(root)
+		if err != nil {
+			yield(WalkDirEntry{Path: root}, err)
+			return
+		}
+		if !rootInfo.IsDir() {
+			yield(WalkDirEntry{Path: root}, &fs.PathError{Op: "walk", Path: root, Err: errors.New("not a directory")})
+			return
+		}
+		w := &dirWalker{opts: opts, yield: yield, ancestors: []fs.FileInfo{rootInfo}}
+		w.walk(root, "", 1, nil)
+	}
+}
+
+// FileGlob returns the files and directories matching pattern
+// with the same pattern syntax as WalkDirOptions, including "**".
+// Paths are returned in lexical order like filepath.Glob returns them,
+// RelPath is relative to the leading directories of pattern
+// that contain no pattern characters.
+// Errors of unreadable directories are returned as ErrorList
+// together with the matches that could be found.
+func FileGlob(pattern string) ([]WalkDirEntry, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+	base := 0
+	for base < len(segments) && !strings.ContainsAny(segments[base], `*?[\`) {
+		base++
+	}
+	root := filepath.FromSlash(strings.Join(segments[:base], "/"))
+	if base == 0 {
+		root = "."
+	} else if root == "" {
+		root = string(filepath.Separator)
+	}
+
+	if base == len(segments) {
+		info, err := os.Stat(root)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []WalkDirEntry{{Path: root, RelPath: info.Name(), Depth: 1, Info: info}}, nil
+	}
+
+	rest := strings.Join(segments[base:], "/")
+	opts := &WalkDirOptions{
+		Include:  []string{rest},
+		Symlinks: WalkDirSymlinksFollow,
+		Dirs:     true,
+	}
+	if !strings.Contains(rest, "**") {
+		opts.MaxDepth = len(segments) - base
+	}
+	var (
+		matches []WalkDirEntry
+		errs    ErrorList
+	)
+	for entry, err := range WalkDir(root, opts) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		matches = append(matches, entry)
+	}
+	return matches, errs.Err()
+}
+
+type dirWalker struct {
+	opts      *WalkDirOptions
+	yield     func(WalkDirEntry, error) bool
+	ancestors []fs.FileInfo
+}
+
+// walk returns false if the iteration was stopped
+func (w *dirWalker) walk(dir, relDir string, depth int, rules []walkIgnoreRule) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !w.yield(WalkDirEntry{Path: dir}, err) {
+		return false
+	}
+	for _, name := range w.opts.IgnoreFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name)) //#nosec G304
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			if !w.yield(WalkDirEntry{Path: filepath.Join(dir, name)}, err) {
+				return false
+			}
+			continue
+		}
+		rules = append(rules[:len(rules):len(rules)], parseWalkIgnoreRules(string(data), relDir)...)
+	}
+
+	for _, entry := range entries {
+		fullPath := filepath.Join(dir, entry.Name())
+		relPath := entry.Name()
+		if relDir != "" {
+			relPath = relDir + "/" + entry.Name()
+		}
+
+		var info fs.FileInfo
+		if entry.Type()&fs.ModeSymlink != 0 {
+			switch w.opts.Symlinks {
+			case WalkDirSymlinksSkip:
+				continue
+			case WalkDirSymlinksFollow:
+				info, err = os.Stat(fullPath)
+			default:
+				info, err = entry.Info()
+			}
+		} else {
+			info, err = entry.Info()
+		}
+		if err != nil {
+			if !w.yield(WalkDirEntry{Path: fullPath}, err) {
+				return false
+			}
+			continue
+		}
+		isDir := info.IsDir()
+
+		if walkMatchAny(w.opts.Exclude, relPath) || walkIgnored(rules, relPath, isDir) {
+			continue
+		}
+		if (!isDir || w.opts.Dirs) && (len(w.opts.Include) == 0 || walkMatchAny(w.opts.Include, relPath)) {
+			if !w.yield(WalkDirEntry{Path: fullPath, RelPath: relPath, Depth: depth, Info: info}, nil) {
+				return false
+			}
+		}
+		if !isDir || (w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth) || w.isAncestor(info) {
+			continue
+		}
+		w.ancestors = append(w.ancestors, info)
+		ok := w.walk(fullPath, relPath, depth+1, rules)
+		w.ancestors = w.ancestors[:len(w.ancestors)-1]
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *dirWalker) isAncestor(info fs.FileInfo) bool {
+	for _, ancestor := range w.ancestors {
+		if os.SameFile(ancestor, info) {
+			return true
+		}
+	}
+	return false
+}
+
+func walkMatchAny(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		if walkGlobMatch(pattern, relPath) ||
+			(!strings.Contains(pattern, "/") && walkGlobMatch(pattern, path.Base(relPath))) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkGlobMatch matches the '/' separated relPath against pattern
+// with "**" segments matching zero or more path segments.
+func walkGlobMatch(pattern, relPath string) bool {
+	return walkGlobMatchSegments(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+}
+
+func walkGlobMatchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+			for i := 0; i <= len(segments); i++ {
+				if walkGlobMatchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// walkIgnoreRule is a pattern line of a .gitignore style file
+type walkIgnoreRule struct {
+	// base is the relative directory of the ignore file
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func parseWalkIgnoreRules(text, base string) (rules []walkIgnoreRule) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		rule := walkIgnoreRule{base: base}
+		if line[0] == '!' {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// walkIgnored returns if the last matching rule ignores relPath
+func walkIgnored(rules []walkIgnoreRule, relPath string, isDir bool) bool {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].match(relPath, isDir) {
+			return !rules[i].negate
+		}
+	}
+	return false
+}
+
+func (r *walkIgnoreRule) match(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(relPath, r.base+"/") {
+			return false
+		}
+		relPath = relPath[len(r.base)+1:]
+	}
+	if r.anchored {
+		return walkGlobMatch(r.pattern, relPath)
+	}
+	return walkGlobMatch(r.pattern, path.Base(relPath))
+}
@@ -0,0 +1,164 @@
+package dry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func walkDirTestTree(t *testing.T) string {
+	dir := t.TempDir()
+	for _, filename := range []string{
+		"main.go",
+		"main_test.go",
+		"README.md",
+		".gitignore",
+		"build/out.bin",
+		"cmd/tool/tool.go",
+		"cmd/tool/.gitignore",
+		"cmd/tool/generated.go",
+		"cmd/tool/keep.log",
+		"logs/app.log",
+		"vendor/lib/lib.go",
+	} {
+		filename = filepath.Join(dir, filepath.FromSlash(filename))
+		err := os.MkdirAll(filepath.Dir(filename), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filename, nil, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	FileSetString(filepath.Join(dir, ".gitignore"), "# comment\n/build/\n*.log\n!keep.log\n")
+	FileSetString(filepath.Join(dir, "cmd/tool/.gitignore"), "generated.go\n")
+	return dir
+}
+
+func walkDirTestRelPaths(t *testing.T, root string, opts *WalkDirOptions) (relPaths []string) {
+	t.Helper()
+	for entry, err := range WalkDir(root, opts) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		relPaths = append(relPaths, entry.RelPath)
+	}
+	return relPaths
+}
+
+func TestWalkDir(t *testing.T) {
+	dir := walkDirTestTree(t)
+
+	relPaths := walkDirTestRelPaths(t, dir, &WalkDirOptions{
+		Include:     []string{"**/*.go", "*.log"},
+		Exclude:     []string{"vendor", "*_test.go"},
+		IgnoreFiles: []string{".gitignore"},
+	})
+	expected := []string{"cmd/tool/keep.log", "cmd/tool/tool.go", "main.go"}
+	if !reflect.DeepEqual(relPaths, expected) {
+		t.Errorf("got %v, expected %v", relPaths, expected)
+	}
+
+	relPaths = walkDirTestRelPaths(t, dir, &WalkDirOptions{MaxDepth: 1, Dirs: true, Exclude: []string{".*"}})
+	expected = []string{"README.md", "build", "cmd", "logs", "main.go", "main_test.go", "vendor"}
+	if !reflect.DeepEqual(relPaths, expected) {
+		t.Errorf("got %v, expected %v", relPaths, expected)
+	}
+
+	count := 0
+	for range WalkDir(dir, nil) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Error("iteration did not stop")
+	}
+}
+
+func TestWalkDirSymlinks(t *testing.T) {
+	dir := walkDirTestTree(t)
+	err := os.Symlink(dir, filepath.Join(dir, "cmd", "loop"))
+	if err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	opts := &WalkDirOptions{Include: []string{"cmd/**"}, Dirs: true}
+	relPaths := walkDirTestRelPaths(t, dir, opts)
+	expected := []string{"cmd", "cmd/loop", "cmd/tool", "cmd/tool/.gitignore", "cmd/tool/generated.go", "cmd/tool/keep.log", "cmd/tool/tool.go"}
+	if !reflect.DeepEqual(relPaths, expected) {
+		t.Errorf("got %v, expected %v", relPaths, expected)
+	}
+
+	opts.Symlinks = WalkDirSymlinksSkip
+	relPaths = walkDirTestRelPaths(t, dir, opts)
+	skipped := append([]string{"cmd"}, expected[2:]...)
+	if !reflect.DeepEqual(relPaths, skipped) {
+		t.Errorf("got %v, expected %v", relPaths, skipped)
+	}
+
+	// Following the link to the root must not recurse endlessly
+	opts.Symlinks = WalkDirSymlinksFollow
+	relPaths = walkDirTestRelPaths(t, dir, opts)
+	if !reflect.DeepEqual(relPaths, expected) {
+		t.Errorf("got %v, expected %v", relPaths, expected)
+	}
+}
+
+func TestFileGlob(t *testing.T) {
+	dir := walkDirTestTree(t)
+
+	matches, err := FileGlob(filepath.Join(dir, "**", "*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, match := range matches {
+		if match.Info == nil {
+			t.Errorf("missing FileInfo for %s", match.Path)
+		}
+		paths = append(paths, match.Path)
+	}
+	expected := []string{filepath.Join(dir, "cmd", "tool", "keep.log"), filepath.Join(dir, "logs", "app.log")}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("got %v, expected %v", paths, expected)
+	}
+
+	matches, err = FileGlob(filepath.Join(dir, "*", "tool"))
+	if err != nil || len(matches) != 1 || matches[0].RelPath != "cmd/tool" || !matches[0].Info.IsDir() {
+		t.Errorf("invalid matches %v, %v", matches, err)
+	}
+
+	matches, err = FileGlob(filepath.Join(dir, "main.go"))
+	if err != nil || len(matches) != 1 {
+		t.Errorf("invalid matches %v, %v", matches, err)
+	}
+
+	_, err = FileGlob(filepath.Join(dir, "[a"))
+	if err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+func Test_walkGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		relPath string
+		match   bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/tool/main.go", true},
+		{"cmd/**", "cmd/tool/main.go", true},
+		{"cmd/**/main.go", "cmd/main.go", true},
+		{"cmd/**/main.go", "pkg/main.go", false},
+		{"a/**/b/**/c", "a/x/b/y/z/c", true},
+	}
+	for _, test := range tests {
+		if walkGlobMatch(test.pattern, test.relPath) != test.match {
+			t.Errorf("walkGlobMatch(%q, %q) != %t", test.pattern, test.relPath, test.match)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package dry
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// xxh64 implements the non-cryptographic xxHash64 with seed zero.
+type xxh64 struct {
+	v     [4]uint64
+	total uint64
+	mem   [32]byte
+	n     int // bytes buffered in mem
+}
+
+// Variables instead of constants for wrapping arithmetic
+var (
+	xxh64Prime1 uint64 = 0x9E3779B185EBCA87
+	xxh64Prime2 uint64 = 0xC2B2AE3D27D4EB4F
+	xxh64Prime3 uint64 = 0x165667B19E3779F9
+	xxh64Prime4 uint64 = 0x85EBCA77C2B2AE63
+	xxh64Prime5 uint64 = 0x27D4EB2F165667C5
+)
+
+func newXXH64() *xxh64 {
+	x := &xxh64{}
+	x.Reset()
+	return x
+}
+
+func (x *xxh64) Size() int      { return 8 }
+func (x *xxh64) BlockSize() int { return 32 }
+
+func (x *xxh64) Reset() {
+	x.v = [4]uint64{xxh64Prime1 + xxh64Prime2, xxh64Prime2, 0, -xxh64Prime1}
+	x.total = 0
+	x.n = 0
+}
+
+func (x *xxh64) Write(p []byte) (n int, err error) {
+	n = len(p)
+	x.total += uint64(n)
+	if x.n+len(p) < 32 {
+		x.n += copy(x.mem[x.n:], p)
+		return n, nil
+	}
+	if x.n > 0 {
+		copied := copy(x.mem[x.n:], p)
+		x.stripe(x.mem[:])
+		p = p[copied:]
+		x.n = 0
+	}
+	for ; len(p) >= 32; p = p[32:] {
+		x.stripe(p)
+	}
+	x.n = copy(x.mem[:], p)
+	return n, nil
+}
+
+func (x *xxh64) stripe(p []byte) {
+	x.v[0] = xxh64Round(x.v[0], binary.LittleEndian.Uint64(p[0:]))
+	x.v[1] = xxh64Round(x.v[1], binary.LittleEndian.Uint64(p[8:]))
+	x.v[2] = xxh64Round(x.v[2], binary.LittleEndian.Uint64(p[16:]))
+	x.v[3] = xxh64Round(x.v[3], binary.LittleEndian.Uint64(p[24:]))
+}
+
+func (x *xxh64) Sum64() uint64 {
+	var h uint64
+	if x.total >= 32 {
+		h = bits.RotateLeft64(x.v[0], 1) + bits.RotateLeft64(x.v[1], 7) +
+			bits.RotateLeft64(x.v[2], 12) + bits.RotateLeft64(x.v[3], 18)
+		for _, v := range x.v {
+			h ^= xxh64Round(0, v)
+			h = h*xxh64Prime1 + xxh64Prime4
+		}
+	} else {
+		h = xxh64Prime5
+	}
+	h += x.total
+
+	p := x.mem[:x.n]
+	for ; len(p) >= 8; p = p[8:] {
+		h ^= xxh64Round(0, binary.LittleEndian.Uint64(p))
+		h = bits.RotateLeft64(h, 27)*xxh64Prime1 + xxh64Prime4
+	}
+	if len(p) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(p)) * xxh64Prime1
+		h = bits.RotateLeft64(h, 23)*xxh64Prime2 + xxh64Prime3
+		p = p[4:]
+	}
+	for _, c := range p {
+		h ^= uint64(c) * xxh64Prime5
+		h = bits.RotateLeft64(h, 11) * xxh64Prime1
+	}
+
+	h ^= h >> 33
+	h *= xxh64Prime2
+	h ^= h >> 29
+	h *= xxh64Prime3
+	h ^= h >> 32
+	return h
+}
+
+func (x *xxh64) Sum(in []byte) []byte {
+	return binary.BigEndian.AppendUint64(in, x.Sum64())
+}
+
+func xxh64Round(acc, input uint64) uint64 {
+	acc += input * xxh64Prime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxh64Prime1
+}